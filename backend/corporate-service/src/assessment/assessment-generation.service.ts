@@ -111,7 +111,8 @@ export class AssessmentGenerationService {
         mainQuestionId: item.source === 'MAIN' ? item.id : undefined,
         openQuestionId: item.source === 'OPEN' ? item.id : undefined,
         questionSequence: seq++,
-        questionOptionsOrder: JSON.stringify(this.shuffleOptions([1, 2, 3, 4])),
+        // Left empty: the exam engine pins a seeded per-candidate order.
+        questionOptionsOrder: null,
         status: 'NOT_ANSWERED',
       });
     }
@@ -406,12 +407,4 @@ export class AssessmentGenerationService {
     }
     return legacyDefault;
   }
-
-  private shuffleOptions(array: number[]): number[] {
-    for (let i = array.length - 1; i > 0; i--) {
-      const j = Math.floor(Math.random() * (i + 1));
-      [array[i], array[j]] = [array[j], array[i]];
-    }
-    return array;
-  }
 }
@@ -14,10 +14,13 @@ func main() {
 	// Initialize Database
 	repository.ConnectDB(cfg)
 
+	// Level scorers, keyed by assessment_levels.pattern_type
+	service.RegisterBuiltinScorers()
+
 	// Start Background Scheduler
 	go service.StartScheduler()
 
-	r := routes.SetupRouter()
+	r := routes.SetupRouter(cfg)
 
 	log.Printf("Exam Engine Service starting on port %s", cfg.Port)
 	if err := r.Run(":" + cfg.Port); err != nil {
@@ -2,6 +2,8 @@ package config
 
 import (
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -14,8 +16,46 @@ type Config struct {
 	DBName      string
 	DBPort      string
 	DatabaseURL string
+
+	// JWT authentication. Exam routes require a bearer token once a JWKS
+	// source is set; JWKSFile wins over JWKSURL. Without one the service
+	// refuses to start unless AuthDisabled is set.
+	JWKSURL      string
+	JWKSFile     string
+	JWTIssuer    string
+	JWTAudience  string
+	AuthDisabled bool
+
+	// CORS. Origins are exact origins or path.Match patterns such as
+	// "https://*.originbi.com" or "http://localhost:*".
+	Env         string
+	CORSOrigins []string
+	CORSMethods []string
+	CORSHeaders []string
+	CORSMaxAge  int // seconds
+
+	// Rate limiting. RateLimitBackend is memory (default), postgres or off;
+	// RateLimits overrides per-route rules, "route:scope" -> "N/unit[:burst]".
+	RateLimitBackend string
+	RateLimits       map[string]string
+
+	// TrustedProxies (IPs or CIDRs) may set X-Forwarded-For / X-Real-IP.
+	// Unset trusts none, so the client IP is the peer address.
+	TrustedProxies []string
 }
 
+// Default CORS settings, used when the matching variable is unset.
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{
+		"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization",
+		"Accept", "Origin", "Cache-Control", "X-Requested-With",
+		"X-Attempt-Session", "X-Device-Fingerprint",
+	}
+	// Development frontends; other environments must list their origins.
+	devCORSOrigins = []string{"http://localhost:*", "http://127.0.0.1:*"}
+)
+
 func LoadConfig() *Config {
 	// Try loading from .env.local first (highest priority)
 	_ = godotenv.Load(".env.local")
@@ -29,6 +69,27 @@ func LoadConfig() *Config {
 		port = "4005"
 	}
 
+	// Unset APP_ENV is treated as a deployment: localhost origins are only
+	// allowed when it is explicitly development or local.
+	env := strings.ToLower(strings.TrimSpace(os.Getenv("APP_ENV")))
+	corsOrigins := splitList(os.Getenv("CORS_ALLOWED_ORIGINS"))
+	if corsOrigins == nil && (env == "development" || env == "local") {
+		corsOrigins = devCORSOrigins
+	}
+	corsMethods := splitList(os.Getenv("CORS_ALLOWED_METHODS"))
+	if corsMethods == nil {
+		corsMethods = defaultCORSMethods
+	}
+	corsHeaders := splitList(os.Getenv("CORS_ALLOWED_HEADERS"))
+	if corsHeaders == nil {
+		corsHeaders = defaultCORSHeaders
+	}
+	authDisabled, _ := strconv.ParseBool(os.Getenv("AUTH_DISABLED"))
+	corsMaxAge, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE"))
+	if err != nil {
+		corsMaxAge = 600
+	}
+
 	return &Config{
 		Port:        port,
 		DBHost:      os.Getenv("DB_HOST"),
@@ -37,5 +98,44 @@ func LoadConfig() *Config {
 		DBName:      os.Getenv("DB_NAME"),
 		DBPort:      os.Getenv("DB_PORT"),
 		DatabaseURL: os.Getenv("DATABASE_URL"),
+		JWKSURL:     os.Getenv("JWT_JWKS_URL"),
+		JWKSFile:    os.Getenv("JWT_JWKS_FILE"),
+		JWTIssuer:   os.Getenv("JWT_ISSUER"),
+		JWTAudience: os.Getenv("JWT_AUDIENCE"),
+
+		AuthDisabled: authDisabled,
+
+		Env:         env,
+		CORSOrigins: corsOrigins,
+		CORSMethods: corsMethods,
+		CORSHeaders: corsHeaders,
+		CORSMaxAge:  corsMaxAge,
+
+		RateLimitBackend: strings.ToLower(os.Getenv("RATE_LIMIT_BACKEND")),
+		RateLimits:       splitPairs(os.Getenv("RATE_LIMITS")),
+
+		TrustedProxies: splitList(os.Getenv("TRUSTED_PROXIES")),
+	}
+}
+
+// splitList parses a comma-separated variable; empty yields nil.
+func splitList(raw string) []string {
+	var items []string
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// splitPairs parses "k=v,k2=v2"; entries without "=" are ignored.
+func splitPairs(raw string) map[string]string {
+	pairs := map[string]string{}
+	for _, item := range splitList(raw) {
+		if k, v, ok := strings.Cut(item, "="); ok {
+			pairs[strings.TrimSpace(k)] = strings.TrimSpace(v)
+		}
 	}
+	return pairs
 }
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"exam-engine/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Headers carrying the device session on answer routes.
+const (
+	headerAttemptSession    = "X-Attempt-Session"
+	headerDeviceFingerprint = "X-Device-Fingerprint"
+)
+
+// deviceCaller describes the acting user together with the device the
+// request came from. fingerprint is the body value on start/takeover and
+// falls back to the header elsewhere.
+func deviceCaller(c *gin.Context, userID int64, fingerprint string) service.Caller {
+	if fingerprint == "" {
+		fingerprint = c.GetHeader(headerDeviceFingerprint)
+	}
+	return service.Caller{
+		UserID:       userID,
+		Fingerprint:  fingerprint,
+		IP:           c.ClientIP(),
+		SessionToken: c.GetHeader(headerAttemptSession),
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"errors"
+	"exam-engine/internal/models"
+	"exam-engine/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// examErrorStatus maps service error codes to HTTP statuses.
+var examErrorStatus = map[string]int{
+	service.ErrCodeTimeUp:           http.StatusConflict,
+	service.ErrCodeAttemptNotFound:  http.StatusNotFound,
+	service.ErrCodeNotInProgress:    http.StatusConflict,
+	service.ErrCodeQuestionNotFound: http.StatusNotFound,
+	service.ErrCodeStaleAnswer:      http.StatusConflict,
+	service.ErrCodeNothingAnswered:  http.StatusUnprocessableEntity,
+	service.ErrCodeInvalidAnswer:    http.StatusUnprocessableEntity,
+	service.ErrCodeLockedUntil:      http.StatusForbidden,
+	service.ErrCodeExpired:          http.StatusGone,
+	service.ErrCodeAlreadyCompleted: http.StatusConflict,
+	service.ErrCodeNotEligible:      http.StatusForbidden,
+	service.ErrCodeDeviceLocked:     http.StatusConflict,
+	service.ErrCodeSessionInvalid:   http.StatusForbidden,
+}
+
+// respondError writes a typed service.ExamError with its code, status and
+// details; any other error keeps the legacy 500 with a prefixed message.
+func respondError(c *gin.Context, err error, prefix string) {
+	var examErr *service.ExamError
+	if errors.As(err, &examErr) {
+		status, ok := examErrorStatus[examErr.Code]
+		if !ok {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, models.ServiceResponse{
+			Status:  "error",
+			Code:    examErr.Code,
+			Message: examErr.Message,
+			Data:    examErr.Details,
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, models.ServiceResponse{
+		Status:  "error",
+		Message: prefix + err.Error(),
+	})
+}
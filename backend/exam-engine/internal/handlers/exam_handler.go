@@ -4,6 +4,7 @@ import (
 	"exam-engine/internal/models"
 	"exam-engine/internal/service"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 )
@@ -43,23 +44,31 @@ func (h *ExamHandler) StartExam(c *gin.Context) {
 		return
 	}
 
-	questions, err := h.service.GetExamQuestions(req.ExamID, req.StudentID) // Pass both ExamID (AttemptID) and StudentID for verification
+	studentID, ok := requireStudentID(c, req.StudentID)
+	if !ok {
+		return
+	}
+
+	caller := deviceCaller(c, studentID, req.DeviceFingerprint)
+	paper, err := h.service.GetExamQuestions(req.ExamID, caller) // Pass both ExamID (AttemptID) and StudentID for verification
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ServiceResponse{
-			Status:  "error",
-			Message: "Failed to fetch questions: " + err.Error(),
-		})
+		respondError(c, err, "Failed to fetch questions: ")
 		return
 	}
 
 	isLast, _ := h.service.IsLastLevel(req.ExamID)
 
-	c.JSON(http.StatusOK, gin.H{
+	resp := gin.H{
 		"status":        "success",
 		"message":       "Exam started",
-		"data":          questions,
+		"data":          paper.Questions,
+		"timing":        paper.Timing,
 		"is_last_level": isLast,
-	})
+	}
+	if paper.SessionToken != "" {
+		resp["session_token"] = paper.SessionToken
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func (h *ExamHandler) SubmitAnswer(c *gin.Context) {
@@ -72,15 +81,183 @@ func (h *ExamHandler) SubmitAnswer(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.SubmitAnswer(ans); err != nil {
-		c.JSON(http.StatusInternalServerError, models.ServiceResponse{
+	userID, ok := actingUserID(c, 0)
+	if !ok {
+		return
+	}
+
+	outcome, err := h.service.SubmitAnswer(ans, deviceCaller(c, userID, ""))
+	if err != nil {
+		respondError(c, err, "Failed to submit answer: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ServiceResponse{
+		Status: "success",
+		Data:   outcome,
+	})
+}
+
+func (h *ExamHandler) SubmitAnswerBatch(c *gin.Context) {
+	var req models.BatchAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ServiceResponse{
 			Status:  "error",
-			Message: "Failed to submit answer: " + err.Error(),
+			Message: "Invalid request payload: " + err.Error(),
 		})
 		return
 	}
 
+	userID, ok := actingUserID(c, 0)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.SubmitAnswerBatch(req, deviceCaller(c, userID, ""))
+	if err != nil {
+		respondError(c, err, "Failed to submit answers: ")
+		return
+	}
+
 	c.JSON(http.StatusOK, models.ServiceResponse{
 		Status: "success",
+		Data:   result,
+	})
+}
+
+func (h *ExamHandler) FinishExam(c *gin.Context) {
+	var req models.ExamFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ServiceResponse{
+			Status:  "error",
+			Message: "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	studentID, ok := requireStudentID(c, req.StudentID)
+	if !ok {
+		return
+	}
+
+	completion, err := h.service.FinishAttempt(req.AttemptID, deviceCaller(c, studentID, ""))
+	if err != nil {
+		respondError(c, err, "Failed to finish attempt: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ServiceResponse{
+		Status:  "success",
+		Message: "Attempt finished",
+		Data:    completion,
+	})
+}
+
+func (h *ExamHandler) GetAttemptState(c *gin.Context) {
+	attemptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ServiceResponse{
+			Status:  "error",
+			Message: "Invalid attempt id",
+		})
+		return
+	}
+	var claimed int64
+	if raw := c.Query("student_id"); raw != "" {
+		if claimed, err = strconv.ParseInt(raw, 10, 64); err != nil {
+			c.JSON(http.StatusBadRequest, models.ServiceResponse{
+				Status:  "error",
+				Message: "Invalid student_id",
+			})
+			return
+		}
+	}
+	studentID, ok := requireStudentID(c, claimed)
+	if !ok {
+		return
+	}
+
+	state, err := h.service.GetAttemptState(attemptID, studentID)
+	if err != nil {
+		respondError(c, err, "Failed to load attempt state: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ServiceResponse{
+		Status: "success",
+		Data:   state,
+	})
+}
+
+func (h *ExamHandler) RecordProctoringEvents(c *gin.Context) {
+	attemptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ServiceResponse{
+			Status:  "error",
+			Message: "Invalid attempt id",
+		})
+		return
+	}
+
+	var batch models.ProctoringEventBatch
+	if err := c.ShouldBindJSON(&batch); err != nil {
+		c.JSON(http.StatusBadRequest, models.ServiceResponse{
+			Status:  "error",
+			Message: "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	userID, ok := actingUserID(c, 0)
+	if !ok {
+		return
+	}
+
+	summary, err := h.service.RecordProctoringEvents(attemptID, deviceCaller(c, userID, ""), batch)
+	if err != nil {
+		respondError(c, err, "Failed to record events: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ServiceResponse{
+		Status: "success",
+		Data:   summary,
+	})
+}
+
+func (h *ExamHandler) TakeOverAttempt(c *gin.Context) {
+	attemptID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, models.ServiceResponse{
+			Status:  "error",
+			Message: "Invalid attempt id",
+		})
+		return
+	}
+
+	var req models.TakeoverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, models.ServiceResponse{
+			Status:  "error",
+			Message: "Invalid request payload: " + err.Error(),
+		})
+		return
+	}
+
+	studentID, ok := requireStudentID(c, req.StudentID)
+	if !ok {
+		return
+	}
+
+	token, err := h.service.TakeOverAttempt(attemptID, deviceCaller(c, studentID, req.DeviceFingerprint))
+	if err != nil {
+		respondError(c, err, "Failed to take over attempt: ")
+		return
+	}
+
+	c.JSON(http.StatusOK, models.ServiceResponse{
+		Status:  "success",
+		Message: "Attempt moved to this device",
+		Data:    gin.H{"session_token": token},
 	})
 }
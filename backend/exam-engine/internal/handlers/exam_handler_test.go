@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"exam-engine/internal/repository"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory SQLite database with the tables a start
+// touches and installs it as repository.DB for the duration of the test.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1) // one connection keeps the in-memory database alive
+
+	// The models' column types and defaults are written for Postgres.
+	postgresTypes := strings.NewReplacer("now()", "CURRENT_TIMESTAMP", "timestamp with time zone", "datetime")
+	db.Callback().Raw().Before("gorm:raw").Register("test:sqlite_ddl", func(tx *gorm.DB) {
+		if sql := tx.Statement.SQL.String(); strings.HasPrefix(sql, "CREATE TABLE") {
+			tx.Statement.SQL.Reset()
+			tx.Statement.SQL.WriteString(postgresTypes.Replace(sql))
+		}
+	})
+	if err := db.AutoMigrate(
+		&models.AssessmentAttempt{}, &models.AssessmentAnswer{}, &models.AssessmentLevel{},
+		&models.AssessmentQuestion{}, &models.AssessmentQuestionOption{}, &models.AssessmentSession{},
+		&models.OpenQuestion{}, &models.OpenQuestionOption{}, &models.OpenQuestionImage{},
+	); err != nil {
+		t.Fatalf("migrate test db: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE originbi_settings (
+		category TEXT, setting_key TEXT, value_type TEXT, value_string TEXT,
+		value_boolean BOOLEAN, value_number NUMERIC, value_json TEXT)`).Error; err != nil {
+		t.Fatalf("create settings: %v", err)
+	}
+
+	previous := repository.DB
+	repository.DB = db
+	t.Cleanup(func() {
+		repository.DB = previous
+		sqlDB.Close()
+	})
+	return db
+}
+
+// mustCreate inserts rows into the test database.
+func mustCreate(t *testing.T, db *gorm.DB, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+}
+
+// collectKeys walks a decoded JSON document and records every object key.
+func collectKeys(v interface{}, keys map[string]bool) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			keys[k] = true
+			collectKeys(child, keys)
+		}
+	case []interface{}:
+		for _, child := range v {
+			collectKeys(child, keys)
+		}
+	}
+}
+
+func TestStartExamResponseHasNoScoringFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := newTestDB(t)
+
+	disc := "D"
+	attempt := models.AssessmentAttempt{UserID: 7, Status: "IN_PROGRESS", Metadata: `{"sincerity_index":90}`}
+	mustCreate(t, db, &attempt)
+
+	main := models.AssessmentQuestion{SetNumber: 1, Category: "ATTENTION_CHECK", QuestionTextEn: "Pick one", Metadata: `{"answer":"A"}`}
+	mustCreate(t, db, &main)
+	mustCreate(t, db,
+		&models.AssessmentQuestionOption{QuestionID: main.ID, DisplayOrder: 1, OptionTextEn: "A", DiscFactor: &disc, ScoreValue: 4, IsCorrect: true, Metadata: `{"distractor":false}`},
+		&models.AssessmentQuestionOption{QuestionID: main.ID, DisplayOrder: 2, OptionTextEn: "B", ScoreValue: 1, Metadata: "{}"},
+	)
+	open := models.OpenQuestion{QuestionType: "IMAGE", MediaType: "IMAGE", QuestionTextEn: "What do you see?", Metadata: `{"points":2}`}
+	mustCreate(t, db, &open)
+	mustCreate(t, db,
+		&models.OpenQuestionOption{OpenQuestionID: open.ID, OptionType: "TEXT", OptionTextEn: "A cat", IsValid: true},
+		&models.OpenQuestionOption{OpenQuestionID: open.ID, OptionType: "TEXT", OptionTextEn: "A dog"},
+	)
+	mustCreate(t, db,
+		&models.AssessmentAnswer{AssessmentAttemptID: attempt.ID, QuestionSource: "MAIN", MainQuestionID: &main.ID, QuestionSequence: 1, AnswerScore: 4, Metadata: `{"correct":true}`},
+		&models.AssessmentAnswer{AssessmentAttemptID: attempt.ID, QuestionSource: "OPEN", OpenQuestionID: &open.ID, QuestionSequence: 2, Metadata: "{}"},
+	)
+
+	router := gin.New()
+	router.POST("/api/v1/exam/start", NewExamHandler().StartExam)
+	body := fmt.Sprintf(`{"student_id": 7, "exam_id": %d}`, attempt.ID)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/exam/start", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", w.Code, w.Body.String())
+	}
+	var resp struct {
+		Data []interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if len(resp.Data) != 2 {
+		t.Fatalf("questions = %d, want 2 (%s)", len(resp.Data), w.Body.String())
+	}
+
+	var doc interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	keys := map[string]bool{}
+	collectKeys(doc, keys)
+	for _, k := range []string{
+		"score_value", "disc_factor", "is_correct", "is_valid", "metadata",
+		"answer_score", "category", "is_attention_fail", "is_distraction_chosen", "sincerity_flag",
+	} {
+		if keys[k] {
+			t.Errorf("start response leaks %q: %s", k, w.Body.String())
+		}
+	}
+}
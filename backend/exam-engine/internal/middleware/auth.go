@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"errors"
+	"exam-engine/internal/config"
+	"exam-engine/internal/models"
+	"exam-engine/internal/repository"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// contextUserKey is where the authenticated user is stored on the Gin context.
+const contextUserKey = "auth_user"
+
+// clockSkew tolerates small clock differences on exp / nbf / iat.
+const clockSkew = time.Minute
+
+// UserResolver maps the token subject to a user row.
+type UserResolver func(sub string) (*models.User, error)
+
+// Authenticator validates RS256 access tokens and resolves their subject to
+// a user.
+type Authenticator struct {
+	keys     jwt.Keyfunc
+	issuer   string
+	audience string
+	resolve  UserResolver
+	now      func() time.Time
+}
+
+// NewAuthenticator builds an Authenticator. An empty issuer or audience is
+// not checked.
+func NewAuthenticator(keys jwt.Keyfunc, issuer, audience string, resolve UserResolver) *Authenticator {
+	return &Authenticator{
+		keys:     keys,
+		issuer:   issuer,
+		audience: audience,
+		resolve:  resolve,
+		now:      time.Now,
+	}
+}
+
+// NewAuthenticatorFromConfig wires the configured JWKS source to the users
+// table. It returns nil when no JWKS source is configured (auth disabled).
+func NewAuthenticatorFromConfig(cfg *config.Config) (*Authenticator, error) {
+	var (
+		keys jwt.Keyfunc
+		err  error
+	)
+	switch {
+	case cfg.JWKSFile != "":
+		keys, err = NewJWKSFromFile(cfg.JWKSFile)
+	case cfg.JWKSURL != "":
+		keys, err = NewJWKSFromURL(cfg.JWKSURL)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return NewAuthenticator(keys, cfg.JWTIssuer, cfg.JWTAudience, ResolveUserByCognitoSub), nil
+}
+
+// ResolveUserByCognitoSub looks the subject up in users.cognito_sub.
+func ResolveUserByCognitoSub(sub string) (*models.User, error) {
+	var user models.User
+	if err := repository.GetDB().Where("cognito_sub = ?", sub).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// RequireUser rejects requests without a valid bearer token and stores the
+// resolved user on the context (see CurrentUser).
+func (a *Authenticator) RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || strings.TrimSpace(token) == "" {
+			abortUnauthorized(c, "missing bearer token")
+			return
+		}
+
+		claims, err := a.verify(strings.TrimSpace(token))
+		if err != nil {
+			fmt.Printf("[Auth] Rejected token: %v\n", err)
+			abortUnauthorized(c, "invalid token")
+			return
+		}
+
+		user, err := a.resolve(claims.Subject)
+		if err != nil || user == nil {
+			fmt.Printf("[Auth] No user for sub=%s: %v\n", claims.Subject, err)
+			abortUnauthorized(c, "unknown user")
+			return
+		}
+
+		c.Set(contextUserKey, user)
+		c.Next()
+	}
+}
+
+// CurrentUser returns the user RequireUser attached to the request.
+func CurrentUser(c *gin.Context) (*models.User, bool) {
+	v, ok := c.Get(contextUserKey)
+	if !ok {
+		return nil, false
+	}
+	user, ok := v.(*models.User)
+	return user, ok && user != nil
+}
+
+func abortUnauthorized(c *gin.Context, message string) {
+	c.AbortWithStatusJSON(http.StatusUnauthorized, models.ServiceResponse{
+		Status:  "error",
+		Code:    "UNAUTHORIZED",
+		Message: message,
+	})
+}
+
+// tokenClaims are the Cognito access token claims the service relies on.
+type tokenClaims struct {
+	jwt.RegisteredClaims
+	ClientID string `json:"client_id"` // Cognito access tokens carry no aud
+	TokenUse string `json:"token_use"`
+}
+
+// verify checks the RS256 signature and the claims of a compact JWT. Only
+// access tokens are accepted; Cognito ID tokens are signed by the same keys
+// but carry token_use "id".
+func (a *Authenticator) verify(token string) (*tokenClaims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(clockSkew),
+		jwt.WithTimeFunc(a.now),
+	}
+	if a.issuer != "" {
+		options = append(options, jwt.WithIssuer(a.issuer))
+	}
+
+	var claims tokenClaims
+	if _, err := jwt.ParseWithClaims(token, &claims, a.keys, options...); err != nil {
+		return nil, err
+	}
+	if claims.TokenUse != "access" {
+		return nil, fmt.Errorf("unexpected token_use %q", claims.TokenUse)
+	}
+	if a.audience != "" && !claims.hasAudience(a.audience) {
+		return nil, errors.New("unexpected audience")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("missing sub")
+	}
+	return &claims, nil
+}
+
+func (c *tokenClaims) hasAudience(want string) bool {
+	return c.ClientID == want || slices.Contains(c.Audience, want)
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"exam-engine/internal/models"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var testKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+
+func testJWKS(t *testing.T, kid string, key *rsa.PublicKey) jwt.Keyfunc {
+	t.Helper()
+	doc := map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	raw, _ := json.Marshal(doc)
+	jwks, err := NewJWKS(raw)
+	if err != nil {
+		t.Fatalf("NewJWKS: %v", err)
+	}
+	return jwks
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, header, claims map[string]interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims(claims))
+	for k, v := range header {
+		token.Header[k] = v
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+// unsignedToken is a token with "alg": "none", which jwt refuses to sign
+// with a key.
+func unsignedToken(t *testing.T, claims map[string]interface{}) string {
+	t.Helper()
+	token := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims(claims))
+	token.Header["kid"] = "k1"
+	signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed
+}
+
+func TestRequireUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	now := time.Now()
+	users := map[string]*models.User{"sub-1": {ID: 42, CognitoSub: "sub-1"}}
+	resolve := func(sub string) (*models.User, error) {
+		if u, ok := users[sub]; ok {
+			return u, nil
+		}
+		return nil, errors.New("not found")
+	}
+	auth := NewAuthenticator(testJWKS(t, "k1", &testKey.PublicKey), "https://issuer", "client-1", resolve)
+
+	router := gin.New()
+	router.GET("/me", auth.RequireUser(), func(c *gin.Context) {
+		user, _ := CurrentUser(c)
+		c.String(http.StatusOK, fmt.Sprint(user.ID))
+	})
+
+	otherKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	header := map[string]interface{}{"alg": "RS256", "kid": "k1"}
+	// claims overrides the claims of a valid token; nil drops a claim.
+	claims := func(overrides map[string]interface{}) map[string]interface{} {
+		c := map[string]interface{}{
+			"sub": "sub-1", "iss": "https://issuer", "aud": "client-1", "token_use": "access",
+			"exp": now.Add(time.Hour).Unix(), "iat": now.Unix(),
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(c, k)
+				continue
+			}
+			c[k] = v
+		}
+		return c
+	}
+
+	cases := []struct {
+		name   string
+		auth   string
+		status int
+		body   string
+	}{
+		{"valid token", "Bearer " + signToken(t, testKey, header, claims(nil)), http.StatusOK, "42"},
+		{"access token client_id", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"aud": nil, "client_id": "client-1"})), http.StatusOK, "42"},
+		{"audience list", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"aud": []string{"x", "client-1"}})), http.StatusOK, "42"},
+		{"missing header", "", http.StatusUnauthorized, ""},
+		{"not a jwt", "Bearer abc", http.StatusUnauthorized, ""},
+		{"wrong key", "Bearer " + signToken(t, otherKey, header, claims(nil)), http.StatusUnauthorized, ""},
+		{"unknown kid", "Bearer " + signToken(t, testKey, map[string]interface{}{"alg": "RS256", "kid": "k2"}, claims(nil)), http.StatusUnauthorized, ""},
+		{"alg none", "Bearer " + unsignedToken(t, claims(nil)), http.StatusUnauthorized, ""},
+		{"id token", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"token_use": "id"})), http.StatusUnauthorized, ""},
+		{"no token_use", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"token_use": nil})), http.StatusUnauthorized, ""},
+		{"expired", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"exp": now.Add(-time.Hour).Unix()})), http.StatusUnauthorized, ""},
+		{"no expiry", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"exp": nil})), http.StatusUnauthorized, ""},
+		{"wrong issuer", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"iss": "https://evil"})), http.StatusUnauthorized, ""},
+		{"wrong audience", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"aud": "other"})), http.StatusUnauthorized, ""},
+		{"unknown user", "Bearer " + signToken(t, testKey, header, claims(map[string]interface{}{"sub": "sub-2"})), http.StatusUnauthorized, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/me", nil)
+			if c.auth != "" {
+				req.Header.Set("Authorization", c.auth)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != c.status {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, c.status, w.Body.String())
+			}
+			if c.body != "" && w.Body.String() != c.body {
+				t.Errorf("body = %q, want %q", w.Body.String(), c.body)
+			}
+		})
+	}
+}
+
+func TestJWKSFromURLThrottlesUnknownKids(t *testing.T) {
+	doc := map[string]interface{}{"keys": []map[string]string{{
+		"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+		"n": base64.RawURLEncoding.EncodeToString(testKey.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(testKey.E)).Bytes()),
+	}}}
+	var fetches atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		json.NewEncoder(w).Encode(doc)
+	}))
+	defer server.Close()
+
+	keys, err := NewJWKSFromURL(server.URL)
+	if err != nil {
+		t.Fatalf("NewJWKSFromURL: %v", err)
+	}
+	claims := map[string]interface{}{"sub": "sub-1", "exp": time.Now().Add(time.Hour).Unix()}
+	for i := 0; i < 5; i++ {
+		token := signToken(t, testKey, map[string]interface{}{"kid": fmt.Sprintf("made-up-%d", i)}, claims)
+		if _, err := jwt.Parse(token, keys); err == nil {
+			t.Fatalf("token with unknown kid accepted")
+		}
+	}
+	if _, err := jwt.Parse(signToken(t, testKey, map[string]interface{}{"kid": "k1"}, claims), keys); err != nil {
+		t.Fatalf("known kid: %v", err)
+	}
+	// The first load plus one refresh for the first unknown kid.
+	if n := fetches.Load(); n != 2 {
+		t.Errorf("fetched the key set %d times, want 2", n)
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"exam-engine/internal/config"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy decides which browser origins may call the API.
+type CORSPolicy struct {
+	Origins []string // exact origins or path.Match patterns; "*" allows any
+	Methods []string
+	Headers []string
+	MaxAge  int // preflight cache, seconds
+}
+
+// CORSPolicyFromConfig builds the policy for the configured environment.
+func CORSPolicyFromConfig(cfg *config.Config) CORSPolicy {
+	return CORSPolicy{
+		Origins: cfg.CORSOrigins,
+		Methods: cfg.CORSMethods,
+		Headers: cfg.CORSHeaders,
+		MaxAge:  cfg.CORSMaxAge,
+	}
+}
+
+// CORS echoes approved origins only (with credentials allowed) and answers
+// preflight requests. Disallowed preflights get 403; other requests from
+// disallowed origins pass through without CORS headers, so the browser
+// blocks the response.
+func CORS(policy CORSPolicy) gin.HandlerFunc {
+	methods := strings.Join(upperAll(policy.Methods), ", ")
+	headers := strings.Join(policy.Headers, ", ")
+	maxAge := strconv.Itoa(policy.MaxAge)
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		c.Writer.Header().Add("Vary", "Origin")
+
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		allowed, anyOrigin := policy.matchOrigin(origin)
+		if !allowed {
+			if preflight {
+				c.AbortWithStatus(http.StatusForbidden)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		// A bare "*" never goes out together with credentials.
+		h := c.Writer.Header()
+		if anyOrigin {
+			h.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			h.Set("Access-Control-Allow-Origin", origin)
+			h.Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		if !preflight {
+			c.Next()
+			return
+		}
+
+		if !policy.allowsMethod(c.GetHeader("Access-Control-Request-Method")) ||
+			!policy.allowsHeaders(c.GetHeader("Access-Control-Request-Headers")) {
+			h.Del("Access-Control-Allow-Origin")
+			h.Del("Access-Control-Allow-Credentials")
+			c.AbortWithStatus(http.StatusForbidden)
+			return
+		}
+		h.Add("Vary", "Access-Control-Request-Method")
+		h.Add("Vary", "Access-Control-Request-Headers")
+		h.Set("Access-Control-Allow-Methods", methods)
+		h.Set("Access-Control-Allow-Headers", headers)
+		if policy.MaxAge > 0 {
+			h.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// matchOrigin reports whether origin is approved, and whether only the "*"
+// entry approved it.
+func (p CORSPolicy) matchOrigin(origin string) (allowed, anyOrigin bool) {
+	origin = strings.ToLower(origin)
+	for _, entry := range p.Origins {
+		entry = strings.ToLower(entry)
+		if entry == "*" {
+			anyOrigin = true
+			continue
+		}
+		if entry == origin {
+			return true, false
+		}
+		if strings.Contains(entry, "*") {
+			if ok, _ := path.Match(entry, origin); ok {
+				return true, false
+			}
+		}
+	}
+	return anyOrigin, anyOrigin
+}
+
+func (p CORSPolicy) allowsMethod(method string) bool {
+	for _, m := range p.Methods {
+		if strings.EqualFold(m, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// allowsHeaders checks every header named in Access-Control-Request-Headers.
+func (p CORSPolicy) allowsHeaders(requested string) bool {
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		found := false
+		for _, h := range p.Headers {
+			if strings.EqualFold(h, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func upperAll(items []string) []string {
+	out := make([]string, len(items))
+	for i, item := range items {
+		out[i] = strings.ToUpper(item)
+	}
+	return out
+}
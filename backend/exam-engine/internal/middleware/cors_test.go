@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func corsRouter(policy CORSPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(CORS(policy))
+	r.POST("/api/v1/exam/answer", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return r
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := corsRouter(CORSPolicy{
+		Origins: []string{"https://app.originbi.com", "https://*.staging.originbi.com", "http://localhost:*"},
+		Methods: []string{"GET", "POST", "DELETE"},
+		Headers: []string{"Content-Type", "Authorization"},
+		MaxAge:  300,
+	})
+
+	cases := []struct {
+		name        string
+		origin      string
+		method      string
+		headers     string
+		status      int
+		allowOrigin string
+	}{
+		{"exact origin", "https://app.originbi.com", "POST", "content-type, authorization", http.StatusNoContent, "https://app.originbi.com"},
+		{"subdomain pattern", "https://qa.staging.originbi.com", "DELETE", "", http.StatusNoContent, "https://qa.staging.originbi.com"},
+		{"port pattern", "http://localhost:3000", "POST", "Content-Type", http.StatusNoContent, "http://localhost:3000"},
+		{"unknown origin", "https://evil.example", "POST", "", http.StatusForbidden, ""},
+		{"look-alike origin", "https://app.originbi.com.evil.example", "POST", "", http.StatusForbidden, ""},
+		{"method not allowed", "https://app.originbi.com", "PUT", "", http.StatusForbidden, ""},
+		{"header not allowed", "https://app.originbi.com", "POST", "X-Secret", http.StatusForbidden, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodOptions, "/api/v1/exam/answer", nil)
+			req.Header.Set("Origin", c.origin)
+			req.Header.Set("Access-Control-Request-Method", c.method)
+			if c.headers != "" {
+				req.Header.Set("Access-Control-Request-Headers", c.headers)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != c.status {
+				t.Fatalf("status = %d, want %d", w.Code, c.status)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != c.allowOrigin {
+				t.Errorf("Allow-Origin = %q, want %q", got, c.allowOrigin)
+			}
+			if c.status != http.StatusNoContent {
+				return
+			}
+			if w.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("credentials not allowed for approved origin")
+			}
+			if got := w.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST, DELETE" {
+				t.Errorf("Allow-Methods = %q", got)
+			}
+			if got := w.Header().Get("Access-Control-Max-Age"); got != "300" {
+				t.Errorf("Max-Age = %q, want 300", got)
+			}
+		})
+	}
+}
+
+func TestCORSSimpleRequests(t *testing.T) {
+	router := corsRouter(CORSPolicy{Origins: []string{"https://app.originbi.com"}, Methods: []string{"POST"}})
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/exam/answer", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK || w.Header().Get("Access-Control-Allow-Origin") != "" {
+		t.Errorf("disallowed origin: status %d, Allow-Origin %q", w.Code, w.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if w.Header().Get("Vary") != "Origin" {
+		t.Errorf("Vary = %q, want Origin", w.Header().Get("Vary"))
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/exam/answer", nil)
+	req.Header.Set("Origin", "https://app.originbi.com")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Header().Get("Access-Control-Allow-Origin") != "https://app.originbi.com" {
+		t.Errorf("approved origin not echoed: %q", w.Header().Get("Access-Control-Allow-Origin"))
+	}
+}
+
+func TestCORSWildcardNeverSendsCredentials(t *testing.T) {
+	router := corsRouter(CORSPolicy{Origins: []string{"*"}, Methods: []string{"POST"}})
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/exam/answer", nil)
+	req.Header.Set("Origin", "https://anything.example")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Header().Get("Access-Control-Allow-Origin") != "*" || w.Header().Get("Access-Control-Allow-Credentials") != "" {
+		t.Errorf("wildcard policy sent Allow-Origin %q with credentials %q",
+			w.Header().Get("Access-Control-Allow-Origin"), w.Header().Get("Access-Control-Allow-Credentials"))
+	}
+}
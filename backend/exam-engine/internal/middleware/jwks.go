@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/MicahParks/keyfunc/v3"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/time/rate"
+)
+
+// jwksRefreshInterval bounds how often an unknown kid triggers a reload, so
+// tokens with made-up key ids cannot hammer the JWKS endpoint.
+const jwksRefreshInterval = time.Minute
+
+// jwksRefreshWait is how long a request with an unknown kid may wait for
+// the refresh limiter before it is rejected.
+const jwksRefreshWait = time.Second
+
+// NewJWKSFromFile reads the key set from a local file once, at startup.
+func NewJWKSFromFile(path string) (jwt.Keyfunc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	return NewJWKS(data)
+}
+
+// NewJWKSFromURL fetches the key set over HTTP (e.g. Cognito's
+// /.well-known/jwks.json). The set is refreshed in the background every
+// hour and on an unknown kid (key rotation), at most once per
+// jwksRefreshInterval; a failed first fetch is retried the same way.
+func NewJWKSFromURL(url string) (jwt.Keyfunc, error) {
+	k, err := keyfunc.NewDefaultOverrideCtx(context.Background(), []string{url}, keyfunc.Override{
+		HTTPTimeout:       10 * time.Second,
+		RateLimitWaitMax:  jwksRefreshWait,
+		RefreshUnknownKID: rate.NewLimiter(rate.Every(jwksRefreshInterval), 1),
+		RefreshErrorHandlerFunc: func(u string) func(context.Context, error) {
+			return func(_ context.Context, err error) {
+				fmt.Printf("[Auth] JWKS refresh from %s failed: %v\n", u, err)
+			}
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	return k.Keyfunc, nil
+}
+
+// NewJWKS builds a key set from an in-memory document.
+func NewJWKS(data []byte) (jwt.Keyfunc, error) {
+	k, err := keyfunc.NewJWKSetJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("jwks: %w", err)
+	}
+	return k.Keyfunc, nil
+}
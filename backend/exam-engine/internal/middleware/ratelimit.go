@@ -0,0 +1,336 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"exam-engine/internal/config"
+	"exam-engine/internal/models"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Rate-limit scopes: what a bucket is keyed by.
+const (
+	ScopeAttempt = "attempt"
+	ScopeIP      = "ip"
+)
+
+// DefaultRateLimits are the per-route rules ("route:scope" -> spec) used
+// unless RATE_LIMITS overrides them. IP limits are generous because a whole
+// school lab can sit behind one address.
+var DefaultRateLimits = map[string]string{
+	"start:attempt":        "30/m:10",
+	"start:ip":             "600/m:200",
+	"answer:attempt":       "120/m:30",
+	"answer:ip":            "1200/m:300",
+	"answer_batch:attempt": "20/m:5",
+	"answer_batch:ip":      "300/m:100",
+	"finish:attempt":       "10/m:5",
+	"finish:ip":            "300/m:100",
+	"state:attempt":        "60/m:20",
+	"state:ip":             "1200/m:300",
+	"events:attempt":       "30/m:10",
+	"events:ip":            "1200/m:300",
+	"takeover:attempt":     "5/m:2",
+	"takeover:ip":          "120/m:30",
+}
+
+// RateLimit is a token bucket: Burst requests at once, refilled at
+// Requests per Per.
+type RateLimit struct {
+	Requests int
+	Per      time.Duration
+	Burst    int
+}
+
+func (l RateLimit) ratePerSecond() float64 {
+	return float64(l.Requests) / l.Per.Seconds()
+}
+
+// idleTTL is a full refill plus a margin; buckets idle for longer are back
+// at Burst anyway and can be dropped.
+func (l RateLimit) idleTTL() time.Duration {
+	return time.Duration(float64(l.Burst)/l.ratePerSecond()*float64(time.Second)) + time.Minute
+}
+
+// ParseRateLimit reads "N/unit" or "N/unit:burst" where unit is s, m or h,
+// e.g. "120/m:30". Without a burst the bucket holds N tokens.
+func ParseRateLimit(spec string) (RateLimit, error) {
+	spec = strings.TrimSpace(spec)
+	rate, burst, hasBurst := strings.Cut(spec, ":")
+	count, unit, ok := strings.Cut(rate, "/")
+	if !ok {
+		return RateLimit{}, fmt.Errorf("rate limit %q: want N/unit", spec)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || n <= 0 {
+		return RateLimit{}, fmt.Errorf("rate limit %q: bad count", spec)
+	}
+
+	limit := RateLimit{Requests: n, Burst: n}
+	switch strings.TrimSpace(unit) {
+	case "s":
+		limit.Per = time.Second
+	case "m":
+		limit.Per = time.Minute
+	case "h":
+		limit.Per = time.Hour
+	default:
+		return RateLimit{}, fmt.Errorf("rate limit %q: unit must be s, m or h", spec)
+	}
+	if hasBurst {
+		b, err := strconv.Atoi(strings.TrimSpace(burst))
+		if err != nil || b <= 0 {
+			return RateLimit{}, fmt.Errorf("rate limit %q: bad burst", spec)
+		}
+		limit.Burst = b
+	}
+	return limit, nil
+}
+
+// Limiter takes one token from the bucket under key. When the bucket is
+// empty it reports how long until a token is available.
+type Limiter interface {
+	Take(key string, limit RateLimit) (allowed bool, retryAfter time.Duration, err error)
+}
+
+// RateLimiter applies per-route rules (one bucket per scope) on top of a
+// Limiter backend.
+type RateLimiter struct {
+	limiter Limiter
+	rules   map[string]map[string]RateLimit // route -> scope -> limit
+}
+
+// NewRateLimiter builds a RateLimiter; rules maps "route:scope" to a spec
+// accepted by ParseRateLimit.
+func NewRateLimiter(limiter Limiter, rules map[string]string) (*RateLimiter, error) {
+	rl := &RateLimiter{limiter: limiter, rules: map[string]map[string]RateLimit{}}
+	for name, spec := range rules {
+		route, scope, ok := strings.Cut(name, ":")
+		if !ok || (scope != ScopeAttempt && scope != ScopeIP) {
+			return nil, fmt.Errorf("rate limit rule %q: want <route>:attempt or <route>:ip", name)
+		}
+		limit, err := ParseRateLimit(spec)
+		if err != nil {
+			return nil, err
+		}
+		if rl.rules[route] == nil {
+			rl.rules[route] = map[string]RateLimit{}
+		}
+		rl.rules[route][scope] = limit
+	}
+	return rl, nil
+}
+
+// NewRateLimiterFromConfig picks the backend from RATE_LIMIT_BACKEND
+// (memory by default, postgres, or off) and applies RATE_LIMITS overrides
+// on top of DefaultRateLimits. It returns nil when rate limiting is off.
+func NewRateLimiterFromConfig(cfg *config.Config, db *gorm.DB) (*RateLimiter, error) {
+	switch cfg.RateLimitBackend {
+	case "off":
+		return nil, nil
+	case "", "memory", "postgres":
+	default:
+		return nil, fmt.Errorf("unknown RATE_LIMIT_BACKEND %q", cfg.RateLimitBackend)
+	}
+
+	rules := make(map[string]string, len(DefaultRateLimits))
+	for name, spec := range DefaultRateLimits {
+		rules[name] = spec
+	}
+	for name, spec := range cfg.RateLimits {
+		rules[name] = spec
+	}
+	rl, err := NewRateLimiter(nil, rules)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RateLimitBackend == "postgres" {
+		// Shared rows carry no rule, so the sweep keeps every bucket for
+		// the slowest refill of any rule.
+		rl.limiter = NewPostgresLimiter(db, rl.longestIdleTTL())
+	} else {
+		rl.limiter = NewMemoryLimiter()
+	}
+	return rl, nil
+}
+
+// longestIdleTTL is the largest idleTTL of the configured rules.
+func (rl *RateLimiter) longestIdleTTL() time.Duration {
+	var longest time.Duration
+	for _, scopes := range rl.rules {
+		for _, limit := range scopes {
+			longest = max(longest, limit.idleTTL())
+		}
+	}
+	return longest
+}
+
+// For returns the middleware enforcing the rules of route. A nil
+// RateLimiter, or a route without rules, lets everything through. It runs
+// after RequireUser, which attemptBucketKey relies on.
+func (rl *RateLimiter) For(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if rl == nil || len(rl.rules[route]) == 0 {
+			c.Next()
+			return
+		}
+
+		keys := map[string]string{ScopeIP: c.ClientIP()}
+		if key := attemptBucketKey(c); key != "" {
+			keys[ScopeAttempt] = key
+		}
+
+		for _, scope := range []string{ScopeAttempt, ScopeIP} {
+			limit, ok := rl.rules[route][scope]
+			value := keys[scope]
+			if !ok || value == "" {
+				continue
+			}
+			allowed, retryAfter, err := rl.limiter.Take(route+":"+scope+":"+value, limit)
+			if err != nil {
+				// Fail open: a broken limiter store must not stop exams.
+				fmt.Printf("[RateLimit] %s %s=%s: %v\n", route, scope, value, err)
+				continue
+			}
+			if !allowed {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				if seconds < 1 {
+					seconds = 1
+				}
+				c.Header("Retry-After", strconv.Itoa(seconds))
+				c.AbortWithStatusJSON(http.StatusTooManyRequests, models.ServiceResponse{
+					Status:  "error",
+					Code:    "RATE_LIMITED",
+					Message: "too many requests, please retry later",
+					Data:    gin.H{"scope": scope, "retry_after_seconds": seconds},
+				})
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// attemptBucketKey names the attempt-scope bucket of a request. Ownership
+// is only checked later by the handler, so keys always include the caller:
+// a bare attempt id would let anyone drain another candidate's bucket.
+// Routes with an :id path param use caller and attempt. The others name the
+// attempt in the body, which a client could rotate to get a fresh bucket on
+// every request, so an authenticated caller gets one bucket for them (a
+// candidate works on one attempt at a time); the body id is only used when
+// authentication is disabled.
+func attemptBucketKey(c *gin.Context) string {
+	user, authenticated := CurrentUser(c)
+	caller := "0"
+	if authenticated {
+		caller = strconv.FormatInt(user.ID, 10)
+	}
+	if id := c.Param("id"); id != "" {
+		return caller + ":" + id
+	}
+	if authenticated {
+		return caller
+	}
+	if id := bodyAttemptID(c); id != "" {
+		return caller + ":" + id
+	}
+	return ""
+}
+
+// bodyAttemptID reads attempt_id / exam_id from a JSON body, which is
+// restored for the handler.
+func bodyAttemptID(c *gin.Context) string {
+	if c.Request.Body == nil || !strings.Contains(c.ContentType(), "json") {
+		return ""
+	}
+	// Only the first MiB is inspected; the handler still reads the whole
+	// body, peeked part first.
+	original := c.Request.Body
+	body, err := io.ReadAll(io.LimitReader(original, 1<<20))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), original), original}
+	if err != nil {
+		return ""
+	}
+
+	var ids struct {
+		AttemptID json.Number `json:"attempt_id"`
+		ExamID    json.Number `json:"exam_id"`
+	}
+	if json.Unmarshal(body, &ids) != nil {
+		return ""
+	}
+	if ids.AttemptID != "" {
+		return ids.AttemptID.String()
+	}
+	return ids.ExamID.String()
+}
+
+// MemoryLimiter keeps token buckets in process memory. It is the default
+// and is exact for a single replica.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens  float64
+	updated time.Time
+	idleTTL time.Duration
+}
+
+// NewMemoryLimiter returns an empty in-memory limiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: map[string]*memoryBucket{}, now: time.Now}
+}
+
+func (m *MemoryLimiter) Take(key string, limit RateLimit) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &memoryBucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	b.idleTTL = limit.idleTTL()
+
+	rate := limit.ratePerSecond()
+	b.tokens = math.Min(float64(limit.Burst), b.tokens+now.Sub(b.updated).Seconds()*rate)
+	b.updated = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - b.tokens) / rate * float64(time.Second)), nil
+}
+
+// sweep drops idle buckets at most once a minute.
+func (m *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, b := range m.buckets {
+		if now.Sub(b.updated) > b.idleTTL {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// postgresSweepInterval is how often idle rows are pruned.
+const postgresSweepInterval = 10 * time.Minute
+
+// PostgresLimiter keeps token buckets in the rate_limit_buckets table
+// (migration 035) so every replica shares them. Each Take is one atomic
+// upsert; the refill is computed from the row's updated_at.
+type PostgresLimiter struct {
+	db      *gorm.DB
+	idleTTL time.Duration
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewPostgresLimiter returns a limiter backed by db. Rows unused for idleTTL
+// are pruned, so it must cover the slowest refill of every rule (see
+// RateLimiter.longestIdleTTL).
+func NewPostgresLimiter(db *gorm.DB, idleTTL time.Duration) *PostgresLimiter {
+	return &PostgresLimiter{db: db, idleTTL: idleTTL}
+}
+
+func (p *PostgresLimiter) Take(key string, limit RateLimit) (bool, time.Duration, error) {
+	p.sweep()
+
+	var row struct {
+		Tokens  float64
+		Allowed bool
+	}
+	err := p.db.Raw(`
+		INSERT INTO rate_limit_buckets AS b (bucket_key, tokens, allowed, updated_at)
+		VALUES (@key, @burst - 1, true, NOW())
+		ON CONFLICT (bucket_key) DO UPDATE SET
+			allowed = LEAST(@burst, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at)) * @rate) >= 1,
+			tokens = LEAST(@burst, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at)) * @rate)
+				- CASE WHEN LEAST(@burst, b.tokens + EXTRACT(EPOCH FROM (NOW() - b.updated_at)) * @rate) >= 1 THEN 1 ELSE 0 END,
+			updated_at = NOW()
+		RETURNING tokens, allowed
+	`, map[string]interface{}{
+		"key":   key,
+		"burst": float64(limit.Burst),
+		"rate":  limit.ratePerSecond(),
+	}).Scan(&row).Error
+	if err != nil {
+		return false, 0, err
+	}
+	if row.Allowed {
+		return true, 0, nil
+	}
+	return false, time.Duration((1 - row.Tokens) / limit.ratePerSecond() * float64(time.Second)), nil
+}
+
+// sweep deletes buckets idle for over idleTTL; by then they are full again.
+func (p *PostgresLimiter) sweep() {
+	p.mu.Lock()
+	if time.Since(p.lastSweep) < postgresSweepInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.lastSweep = time.Now()
+	p.mu.Unlock()
+
+	err := p.db.Exec("DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => ?)", p.idleTTL.Seconds()).Error
+	if err != nil {
+		fmt.Printf("[RateLimit] sweep failed: %v\n", err)
+	}
+}
@@ -0,0 +1,212 @@
+package middleware
+
+import (
+	"exam-engine/internal/models"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	cases := map[string]RateLimit{
+		"120/m":   {Requests: 120, Per: time.Minute, Burst: 120},
+		"10/s:3":  {Requests: 10, Per: time.Second, Burst: 3},
+		" 5/h:1 ": {Requests: 5, Per: time.Hour, Burst: 1},
+	}
+	for spec, want := range cases {
+		if got, err := ParseRateLimit(spec); err != nil || got != want {
+			t.Errorf("ParseRateLimit(%q) = %+v, %v; want %+v", spec, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "10", "0/m", "10/d", "10/m:0", "x/m"} {
+		if _, err := ParseRateLimit(bad); err == nil {
+			t.Errorf("ParseRateLimit(%q) accepted", bad)
+		}
+	}
+}
+
+func TestMemoryLimiterRefills(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	m := NewMemoryLimiter()
+	m.now = func() time.Time { return now }
+	limit := RateLimit{Requests: 60, Per: time.Minute, Burst: 2}
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := m.Take("k", limit); !ok {
+			t.Fatalf("request %d within burst denied", i+1)
+		}
+	}
+	ok, retry, _ := m.Take("k", limit)
+	if ok || retry != time.Second {
+		t.Fatalf("over burst: allowed=%v retry=%v, want denied with 1s", ok, retry)
+	}
+	if ok, _, _ := m.Take("other", limit); !ok {
+		t.Error("buckets are not independent per key")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _, _ := m.Take("k", limit); !ok {
+		t.Error("token not refilled after 1s")
+	}
+}
+
+func TestRateLimiterMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rl, err := NewRateLimiter(NewMemoryLimiter(), map[string]string{
+		"answer:attempt": "1/m",
+		"answer:ip":      "100/m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.POST("/answer", rl.For("answer"), func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+	router.POST("/unlimited", rl.For("other"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	post := func(path, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := post("/answer", `{"attempt_id": 7}`)
+	if first.Code != http.StatusOK || first.Body.String() != `{"attempt_id": 7}` {
+		t.Fatalf("first request: %d %q (body must reach the handler)", first.Code, first.Body.String())
+	}
+
+	second := post("/answer", `{"attempt_id": 7}`)
+	if second.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want 429", second.Code)
+	}
+	if second.Header().Get("Retry-After") != "60" {
+		t.Errorf("Retry-After = %q, want 60", second.Header().Get("Retry-After"))
+	}
+
+	if w := post("/answer", `{"attempt_id": 8}`); w.Code != http.StatusOK {
+		t.Errorf("other attempt limited: %d", w.Code)
+	}
+	if w := post("/unlimited", `{"attempt_id": 7}`); w.Code != http.StatusOK {
+		t.Errorf("route without rules limited: %d", w.Code)
+	}
+
+	// Only the first MiB is peeked at; the handler still gets all of it.
+	large := `{"attempt_id": 9, "padding": "` + strings.Repeat("x", 2<<20) + `"}`
+	if w := post("/answer", large); w.Code != http.StatusOK || w.Body.String() != large {
+		t.Errorf("large body: %d, %d of %d bytes reached the handler", w.Code, w.Body.Len(), len(large))
+	}
+}
+
+func TestRateLimiterKeysAttemptsByCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rl, err := NewRateLimiter(NewMemoryLimiter(), map[string]string{
+		"takeover:attempt": "5/m:2",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	// Stand-in for RequireUser: the caller's id comes from a test header.
+	router.Use(func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		c.Set(contextUserKey, &models.User{ID: id})
+	})
+	router.POST("/attempts/:id/takeover", rl.For("takeover"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	post := func(user string) int {
+		req := httptest.NewRequest(http.MethodPost, "/attempts/7/takeover", nil)
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// A second user hammering the victim's attempt id only drains their own bucket.
+	limited := false
+	for i := 0; i < 10; i++ {
+		limited = limited || post("2") == http.StatusTooManyRequests
+	}
+	if !limited {
+		t.Fatal("attacker was never limited")
+	}
+	for i := 0; i < 2; i++ {
+		if code := post("1"); code != http.StatusOK {
+			t.Fatalf("owner request %d: status %d, want 200", i+1, code)
+		}
+	}
+}
+
+func TestRateLimiterIgnoresRotatedBodyAttemptIDs(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	rl, err := NewRateLimiter(NewMemoryLimiter(), map[string]string{
+		"answer:attempt": "2/m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		id, _ := strconv.ParseInt(c.GetHeader("X-Test-User"), 10, 64)
+		c.Set(contextUserKey, &models.User{ID: id})
+	})
+	router.POST("/answer", rl.For("answer"), func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	post := func(user string, attemptID int) int {
+		body := `{"attempt_id": ` + strconv.Itoa(attemptID) + `}`
+		req := httptest.NewRequest(http.MethodPost, "/answer", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// A fresh attempt_id on every request does not buy a fresh bucket.
+	for i := 1; i <= 2; i++ {
+		if code := post("1", i); code != http.StatusOK {
+			t.Fatalf("request %d: status %d, want 200", i, code)
+		}
+	}
+	if code := post("1", 3); code != http.StatusTooManyRequests {
+		t.Errorf("rotated attempt_id: status %d, want 429", code)
+	}
+	if code := post("2", 3); code != http.StatusOK {
+		t.Errorf("other caller limited: %d", code)
+	}
+}
+
+func TestLongestIdleTTL(t *testing.T) {
+	rl, err := NewRateLimiter(nil, map[string]string{
+		"answer:ip":        "60/m:10",  // 10s to refill
+		"takeover:attempt": "6/h:2",    // 20m to refill
+		"state:attempt":    "100/s:50", // 0.5s to refill
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := rl.longestIdleTTL(), 21*time.Minute; got != want {
+		t.Errorf("longestIdleTTL = %v, want %v", got, want)
+	}
+}
+
+func TestNewRateLimiterRejectsBadRules(t *testing.T) {
+	if _, err := NewRateLimiter(NewMemoryLimiter(), map[string]string{"answer:user": "1/m"}); err == nil {
+		t.Error("unknown scope accepted")
+	}
+	if _, err := NewRateLimiter(NewMemoryLimiter(), map[string]string{"answer:ip": "fast"}); err == nil {
+		t.Error("bad spec accepted")
+	}
+}
@@ -425,3 +425,20 @@ type GroupAssessment struct {
 	CreatedAt          time.Time  `gorm:"default:now()" json:"created_at"`
 	UpdatedAt          time.Time  `gorm:"default:now()" json:"updated_at"`
 }
+
+// Table: assessment_proctoring_events
+type ProctoringEvent struct {
+	ID                  int64     `gorm:"primaryKey;autoIncrement" json:"id"`
+	AssessmentAttemptID int64     `gorm:"not null" json:"assessment_attempt_id"`
+	UserID              int64     `gorm:"not null" json:"user_id"`
+	EventType           string    `gorm:"type:varchar(40);not null" json:"event_type"`
+	OccurredAt          time.Time `gorm:"type:timestamp with time zone;not null" json:"occurred_at"`
+	DurationMs          int       `gorm:"default:0" json:"duration_ms"`
+	ClientEventID       *string   `gorm:"type:varchar(100)" json:"client_event_id"`
+	Metadata            string    `gorm:"type:jsonb;default:'{}'" json:"metadata"`
+	CreatedAt           time.Time `gorm:"default:now()" json:"created_at"`
+}
+
+func (ProctoringEvent) TableName() string {
+	return "assessment_proctoring_events"
+}
@@ -1,25 +1,249 @@
 package models
 
+import "time"
+
 // StudentAnswer represents the payload for submitting an answer
 type StudentAnswer struct {
 	AttemptID          int64  `json:"attempt_id" binding:"required"`
 	QuestionID         int64  `json:"question_id" binding:"required"`
-	SelectedOption     int64  `json:"selected_option" binding:"required"`
-	TimeTaken          int    `json:"time_taken"` // in seconds
+	SelectedOption     int64  `json:"selected_option"` // single-select questions
+	TimeTaken          int    `json:"time_taken"`      // in seconds
 	AnswerChangeCount  int    `json:"answer_change_count"`
 	QuestionSource     string `json:"question_source"`      // MAIN or OPEN
 	AssessmentAnswerID int64  `json:"assessment_answer_id"` // PK of assessment_answers table
+
+	// SelectedOptions carries every picked option of a multi-select question.
+	// Either this or SelectedOption must be set.
+	SelectedOptions []int64 `json:"selected_options"`
+
+	// Typed responses for text, number, essay and ranking questions. The
+	// question decides which one is required; ResponseType is an optional
+	// cross-check sent by the client.
+	ResponseType string   `json:"response_type"`
+	AnswerText   *string  `json:"answer_text"`
+	AnswerNumber *float64 `json:"answer_number"`
+	Ranking      []int64  `json:"ranking"`
+
+	// Retry / ordering guards (optional). A repeated idempotency_key is
+	// ignored; a write older than the last applied one (by client_seq, else
+	// client_ts) is rejected as stale.
+	IdempotencyKey  string     `json:"idempotency_key"`
+	ClientSeq       int64      `json:"client_seq"`
+	ClientTimestamp *time.Time `json:"client_ts"`
+}
+
+// AnswerOutcome tells the client whether its answer was applied or was a
+// duplicate of one already applied.
+type AnswerOutcome struct {
+	AssessmentAnswerID int64  `json:"assessment_answer_id"`
+	Outcome            string `json:"outcome"` // APPLIED or DUPLICATE
+}
+
+// BatchAnswerRequest submits answers the client queued while offline. Items
+// are applied in order; their attempt_id may be omitted.
+type BatchAnswerRequest struct {
+	AttemptID int64           `json:"attempt_id" binding:"required"`
+	Answers   []StudentAnswer `json:"answers" binding:"required,min=1,max=200"`
+}
+
+// BatchAnswerResult is the outcome of one item of a batch.
+type BatchAnswerResult struct {
+	Index              int    `json:"index"`
+	QuestionID         int64  `json:"question_id"`
+	AssessmentAnswerID int64  `json:"assessment_answer_id,omitempty"`
+	Status             string `json:"status"` // SAVED, DUPLICATE or REJECTED
+	Code               string `json:"code,omitempty"`
+	Message            string `json:"message,omitempty"`
+}
+
+// BatchAnswerResponse reports every item plus whether the batch completed
+// the attempt.
+type BatchAnswerResponse struct {
+	AttemptID        int64               `json:"attempt_id"`
+	Saved            int                 `json:"saved"`
+	Duplicates       int                 `json:"duplicates"`
+	Rejected         int                 `json:"rejected"`
+	AttemptCompleted bool                `json:"attempt_completed"`
+	Results          []BatchAnswerResult `json:"results"`
+}
+
+// ProctoringEventBatch is a batch of client proctoring events for one attempt.
+type ProctoringEventBatch struct {
+	Events []ProctoringEventInput `json:"events" binding:"required,min=1,max=500,dive"`
+}
+
+// ProctoringEventInput is one event as reported by the browser.
+type ProctoringEventInput struct {
+	Type          string                 `json:"type" binding:"required"` // TAB_SWITCH, FULLSCREEN_EXIT, PASTE, COPY, FOCUS_LOST
+	OccurredAt    *time.Time             `json:"occurred_at"`             // defaults to receive time
+	DurationMs    int                    `json:"duration_ms"`             // e.g. how long the tab was hidden
+	ClientEventID string                 `json:"client_event_id"`         // makes retries idempotent
+	Details       map[string]interface{} `json:"details"`
+}
+
+// ProctoringSummary reports what was stored and the attempt's running totals.
+type ProctoringSummary struct {
+	AttemptID      int64            `json:"attempt_id"`
+	Accepted       int              `json:"accepted"`
+	Duplicates     int              `json:"duplicates"`
+	Rejected       int              `json:"rejected"`
+	Counters       map[string]int64 `json:"counters"`
+	IntegrityScore float64          `json:"integrity_score"`
 }
 
 // ExamStartRequest represents the request to start an exam
 type ExamStartRequest struct {
-	StudentID int64 `json:"student_id" binding:"required"`
-	ExamID    int64 `json:"exam_id" binding:"required"`
+	StudentID int64 `json:"student_id"` // Taken from the token when auth is on
+
+	ExamID int64 `json:"exam_id" binding:"required"`
+
+	// DeviceFingerprint identifies the browser; required when the
+	// single-device lock is on.
+	DeviceFingerprint string `json:"device_fingerprint"`
+}
+
+// TakeoverRequest moves an attempt's device session to the calling device.
+type TakeoverRequest struct {
+	StudentID int64 `json:"student_id"` // Taken from the token when auth is on
+
+	DeviceFingerprint string `json:"device_fingerprint" binding:"required"`
+}
+
+// ExamFinishRequest asks the engine to score an attempt with whatever has
+// been answered so far.
+type ExamFinishRequest struct {
+	StudentID int64 `json:"student_id"` // Taken from the token when auth is on
+
+	AttemptID int64 `json:"attempt_id" binding:"required"`
+}
+
+// AttemptCompletion reports the outcome of finishing an attempt.
+type AttemptCompletion struct {
+	AttemptID        int64  `json:"attempt_id"`
+	Status           string `json:"status"`
+	AlreadyCompleted bool   `json:"already_completed"`
+	TotalQuestions   int64  `json:"total_questions"`
+	AnsweredCount    int64  `json:"answered_count"`
+	UnansweredCount  int64  `json:"unanswered_count"`
+	SessionCompleted bool   `json:"session_completed"`
+	NextLevelNumber  int    `json:"next_level_number,omitempty"`
 }
 
 // ServiceResponse is a standard API response wrapper
 type ServiceResponse struct {
 	Status  string      `json:"status"`
+	Code    string      `json:"code,omitempty"` // Machine-readable error code
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
 }
+
+// ExamQuestion is the candidate-facing view of one assessment_answers slot.
+// It is the only question shape the exam routes emit: scoring fields such as
+// score_value, disc_factor, is_correct, is_valid and question/option metadata
+// are never copied onto it. JSON names mirror the legacy AssessmentAnswer
+// payload so the exam runner keeps working unchanged.
+type ExamQuestion struct {
+	ID                  int64              `json:"id"` // assessment_answers.id
+	AssessmentAttemptID int64              `json:"assessment_attempt_id"`
+	QuestionSource      string             `json:"question_source"`
+	QuestionSequence    int                `json:"question_sequence"`
+	Status              string             `json:"status"`
+	MainQuestionID      *int64             `json:"main_question_id,omitempty"`
+	OpenQuestionID      *int64             `json:"open_question_id,omitempty"`
+	MainOptionID        *int64             `json:"main_option_id,omitempty"`
+	OpenOptionID        *int64             `json:"open_option_id,omitempty"`
+	MainQuestion        *CandidateQuestion `json:"main_question,omitempty"`
+	OpenQuestion        *CandidateQuestion `json:"open_question,omitempty"`
+}
+
+// CandidateQuestion is the question text, context and media shown to the
+// candidate, with options already arranged in the attempt's display order.
+type CandidateQuestion struct {
+	ID             int64                    `json:"id"`
+	QuestionType   string                   `json:"question_type,omitempty"`
+	MediaType      string                   `json:"media_type,omitempty"`
+	ContextTextEn  *string                  `json:"context_text_en"`
+	ContextTextTa  *string                  `json:"context_text_ta"`
+	QuestionTextEn string                   `json:"question_text_en"`
+	QuestionTextTa *string                  `json:"question_text_ta"`
+	Question       string                   `json:"question,omitempty"` // Compat for open questions
+	MultiSelect    bool                     `json:"multi_select,omitempty"`
+	MinSelections  int                      `json:"min_selections,omitempty"`
+	MaxSelections  int                      `json:"max_selections,omitempty"`
+	ResponseType   string                   `json:"response_type,omitempty"` // text, number, essay or ranking
+	MaxLength      int                      `json:"max_length,omitempty"`
+	MinValue       *float64                 `json:"min_value,omitempty"`
+	MaxValue       *float64                 `json:"max_value,omitempty"`
+	AudioFile      string                   `json:"audio_file,omitempty"`
+	VideoFile      string                   `json:"video_file,omitempty"`
+	DocumentFile   string                   `json:"document_file,omitempty"`
+	Images         []CandidateQuestionImage `json:"images,omitempty"`
+	Options        []CandidateOption        `json:"options"`
+}
+
+// CandidateQuestionImage is an image attached to an open question.
+type CandidateQuestionImage struct {
+	ID           int64  `json:"id"`
+	ImageURL     string `json:"image_url"`
+	DisplayOrder int    `json:"display_order"`
+}
+
+// CandidateOption is a selectable option without any scoring information.
+type CandidateOption struct {
+	ID              int64   `json:"id"`
+	OptionTextEn    string  `json:"option_text_en"`
+	OptionTextTa    *string `json:"option_text_ta"`
+	OptionText      string  `json:"option_text"` // Compat: English text
+	OptionImageFile string  `json:"option_image_file,omitempty"`
+}
+
+// AttemptTiming is the server-authoritative clock for an attempt. Clients
+// should count down from RemainingSeconds rather than their own start time.
+type AttemptTiming struct {
+	StartedAt        *time.Time `json:"started_at"`
+	MustFinishBy     *time.Time `json:"must_finish_by"`
+	RemainingSeconds *int64     `json:"remaining_seconds"`
+	ServerTime       time.Time  `json:"server_time"`
+}
+
+// ExamPaper is what the start/resume path returns: the candidate-facing
+// questions plus the attempt's timing, and the device session token when the
+// single-device lock is on.
+type ExamPaper struct {
+	Questions    []ExamQuestion
+	Timing       AttemptTiming
+	SessionToken string
+}
+
+// AttemptState is the read-only snapshot the runner uses to restore an exam
+// after a reload or device switch.
+type AttemptState struct {
+	AttemptID             int64         `json:"attempt_id"`
+	Status                string        `json:"status"`
+	LevelNumber           int           `json:"level_number"`
+	IsLastLevel           bool          `json:"is_last_level"`
+	UnlockAt              *time.Time    `json:"unlock_at"`
+	ExpiresAt             *time.Time    `json:"expires_at"`
+	CompletedAt           *time.Time    `json:"completed_at"`
+	Timing                AttemptTiming `json:"timing"`
+	TotalQuestions        int           `json:"total_questions"`
+	AnsweredCount         int           `json:"answered_count"`
+	CurrentSequence       *int          `json:"current_sequence"` // First unanswered; nil when all answered
+	AnsweredQuestionIDs   []int64       `json:"answered_question_ids"`
+	UnansweredQuestionIDs []int64       `json:"unanswered_question_ids"`
+	Answers               []AnswerState `json:"answers"`
+}
+
+// AnswerState is the candidate's own progress on one question slot.
+type AnswerState struct {
+	AssessmentAnswerID int64   `json:"assessment_answer_id"`
+	QuestionID         int64   `json:"question_id"`
+	QuestionSource     string  `json:"question_source"`
+	QuestionSequence   int     `json:"question_sequence"`
+	Status             string  `json:"status"`
+	SelectedOptionID   *int64  `json:"selected_option_id"`
+	SelectedOptionIDs  []int64 `json:"selected_option_ids,omitempty"` // multi-select answers
+	AnswerText         string  `json:"answer_text,omitempty"`         // typed responses
+	TimeSpentSeconds   int     `json:"time_spent_seconds"`
+	AnswerChangeCount  int     `json:"answer_change_count"`
+}
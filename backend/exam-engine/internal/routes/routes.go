@@ -1,40 +1,79 @@
 package routes
 
 import (
+	"exam-engine/internal/config"
 	"exam-engine/internal/handlers"
+	"exam-engine/internal/middleware"
+	"exam-engine/internal/models"
+	"exam-engine/internal/repository"
+	"log"
+	"net/http"
 
 	"github.com/gin-gonic/gin"
 )
 
-func SetupRouter() *gin.Engine {
+func SetupRouter(cfg *config.Config) *gin.Engine {
 	r := gin.Default()
 
-	// CORS Middleware
-	r.Use(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
-			return
-		}
+	// Client IPs (rate limits, device locks) only come from forwarding
+	// headers set by these proxies.
+	if err := r.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
-		c.Next()
-	})
+	// CORS Middleware (origins, methods and headers from config)
+	r.Use(middleware.CORS(middleware.CORSPolicyFromConfig(cfg)))
 
 	examHandler := handlers.NewExamHandler()
 
+	// Rate Limits (per attempt and per client IP, per route)
+	limits, err := middleware.NewRateLimiterFromConfig(cfg, repository.GetDB())
+	if err != nil {
+		log.Fatalf("Invalid rate limit configuration: %v", err)
+	}
+
 	// Health Check
 	r.GET("/health", examHandler.HealthCheck)
 
 	// Exam Routes
 	api := r.Group("/api/v1")
+	auth, err := middleware.NewAuthenticatorFromConfig(cfg)
+	if err != nil {
+		log.Fatalf("Invalid JWT configuration: %v", err)
+	}
+	switch {
+	case auth != nil:
+		api.Use(auth.RequireUser())
+	case cfg.AuthDisabled:
+		log.Println("WARNING: AUTH_DISABLED=true - exam routes accept unauthenticated requests")
+	default:
+		log.Fatalf("JWT_JWKS_URL / JWT_JWKS_FILE not set; set AUTH_DISABLED=true to run without authentication")
+	}
 	{
-		api.POST("/exam/start", examHandler.StartExam)
-		api.POST("/exam/answer", examHandler.SubmitAnswer)
+		api.POST("/exam/start", limits.For("start"), examHandler.StartExam)
+		api.POST("/exam/answer", limits.For("answer"), examHandler.SubmitAnswer)
+		// Gin cannot match a literal ":" inside a segment, so custom methods
+		// such as "answers:batch" share one parameter route.
+		api.POST("/exam/:action", onlyAction("answers:batch"), limits.For("answer_batch"), examHandler.SubmitAnswerBatch)
+		api.POST("/exam/finish", limits.For("finish"), examHandler.FinishExam)
+		api.GET("/exam/attempts/:id/state", limits.For("state"), examHandler.GetAttemptState)
+		api.POST("/exam/attempts/:id/events", limits.For("events"), examHandler.RecordProctoringEvents)
+		api.POST("/exam/attempts/:id/takeover", limits.For("takeover"), examHandler.TakeOverAttempt)
 	}
 
 	return r
 }
+
+// onlyAction answers 404 unless the :action segment is action.
+func onlyAction(action string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Param("action") != action {
+			c.AbortWithStatusJSON(http.StatusNotFound, models.ServiceResponse{
+				Status:  "error",
+				Message: "route not found",
+			})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,41 @@
+package routes
+
+import (
+	"exam-engine/internal/config"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestExamRoutesMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := SetupRouter(&config.Config{RateLimitBackend: "off", AuthDisabled: true})
+
+	// An empty payload fails binding in the handler, before any database
+	// access: a 400 proves the route matched.
+	cases := []struct {
+		method, path string
+		status       int
+	}{
+		{http.MethodPost, "/api/v1/exam/answers:batch", http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/exam/answer", http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/exam/start", http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/exam/finish", http.StatusBadRequest},
+		{http.MethodPost, "/api/v1/exam/answers:delete", http.StatusNotFound},
+		{http.MethodPost, "/api/v1/exam/answers", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, c.path, strings.NewReader("{}"))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != c.status {
+				t.Errorf("status = %d, want %d (%s)", w.Code, c.status, w.Body.String())
+			}
+		})
+	}
+}
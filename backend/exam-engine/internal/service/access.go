@@ -0,0 +1,47 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"time"
+)
+
+// checkAttemptAccess enforces the attempt's status and its unlock/expiry
+// window. It runs on start and on every answer so a candidate can neither
+// open a level early nor keep working on one that is closed. The returned
+// errors carry the timestamps the frontend needs for countdowns.
+func checkAttemptAccess(attempt models.AssessmentAttempt, now time.Time) error {
+	switch attempt.Status {
+	case "COMPLETED":
+		return &ExamError{
+			Code:    ErrCodeAlreadyCompleted,
+			Message: "this assessment attempt is already completed",
+			Details: map[string]interface{}{"completed_at": attempt.CompletedAt},
+		}
+	case "EXPIRED", "PARTIALLY_EXPIRED":
+		return &ExamError{
+			Code:    ErrCodeExpired,
+			Message: "this assessment attempt has expired",
+			Details: map[string]interface{}{"status": attempt.Status, "expires_at": attempt.ExpiresAt, "server_time": now},
+		}
+	}
+
+	if attempt.UnlockAt != nil && now.Before(*attempt.UnlockAt) {
+		return &ExamError{
+			Code:    ErrCodeLockedUntil,
+			Message: "this assessment attempt is not unlocked yet",
+			Details: map[string]interface{}{
+				"unlock_at":            attempt.UnlockAt,
+				"server_time":          now,
+				"seconds_until_unlock": int64(attempt.UnlockAt.Sub(now).Seconds()),
+			},
+		}
+	}
+	if attempt.ExpiresAt != nil && now.After(*attempt.ExpiresAt) {
+		return &ExamError{
+			Code:    ErrCodeExpired,
+			Message: "this assessment attempt has expired",
+			Details: map[string]interface{}{"status": attempt.Status, "expires_at": attempt.ExpiresAt, "server_time": now},
+		}
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"errors"
+	"exam-engine/internal/models"
+	"testing"
+	"time"
+)
+
+func TestCheckAttemptAccess(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+
+	cases := []struct {
+		name    string
+		attempt models.AssessmentAttempt
+		want    string
+	}{
+		{"open window", models.AssessmentAttempt{Status: "NOT_STARTED", UnlockAt: &past, ExpiresAt: &future}, ""},
+		{"no window", models.AssessmentAttempt{Status: "IN_PROGRESS"}, ""},
+		{"not unlocked", models.AssessmentAttempt{Status: "NOT_STARTED", UnlockAt: &future}, ErrCodeLockedUntil},
+		{"past expiry", models.AssessmentAttempt{Status: "IN_PROGRESS", ExpiresAt: &past}, ErrCodeExpired},
+		{"marked expired", models.AssessmentAttempt{Status: "PARTIALLY_EXPIRED"}, ErrCodeExpired},
+		{"completed", models.AssessmentAttempt{Status: "COMPLETED", CompletedAt: &past}, ErrCodeAlreadyCompleted},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkAttemptAccess(c.attempt, now)
+			if c.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var examErr *ExamError
+			if !errors.As(err, &examErr) || examErr.Code != c.want {
+				t.Fatalf("err = %v, want %s", err, c.want)
+			}
+		})
+	}
+
+	err := checkAttemptAccess(models.AssessmentAttempt{Status: "NOT_STARTED", UnlockAt: &future}, now)
+	if got := err.(*ExamError).Details["seconds_until_unlock"]; got != int64(3600) {
+		t.Errorf("seconds_until_unlock = %v, want 3600", got)
+	}
+}
@@ -0,0 +1,176 @@
+package service
+
+import (
+	"math"
+
+	"gorm.io/gorm"
+)
+
+// anomalyPolicy configures the response-pattern signals that lower the
+// sincerity index. Each weight is the number of points taken off when every
+// answered question shows the signal; smaller rates scale it down.
+type anomalyPolicy struct {
+	// Speeding: answers faster than MinReadSeconds. Answers without a
+	// recorded time (0 seconds) are not counted.
+	MinReadSeconds float64 `json:"speeding_min_seconds"`
+	SpeedingWeight float64 `json:"speeding_weight"`
+	// Straight-lining: MinRun or more consecutive answers on the same
+	// on-screen option position.
+	StraightLineMinRun int     `json:"straight_line_min_run"`
+	StraightLineWeight float64 `json:"straight_line_weight"`
+	// Flipping: a single answer changed FlipThreshold times or more.
+	FlipThreshold int     `json:"flip_threshold"`
+	FlipWeight    float64 `json:"flip_weight"`
+}
+
+// defaultAnomalyPolicy ships every weight at 0, so the signals are recorded
+// but change no sincerity index until an operator sets a weight in
+// originbi_settings.
+var defaultAnomalyPolicy = anomalyPolicy{
+	MinReadSeconds:     2,
+	StraightLineMinRun: 8,
+	FlipThreshold:      3,
+}
+
+// answerPattern is what the detectors need from one answered question.
+type answerPattern struct {
+	TimeSpentSeconds  int
+	AnswerChangeCount int
+	// Position is the 1-based on-screen position of the chosen option, 0
+	// when there is none (multi-select, typed or unanswered).
+	Position int
+}
+
+// anomalySignal is one itemised signal in the attempt metadata.
+type anomalySignal struct {
+	Flagged int     `json:"flagged"`
+	Rate    float64 `json:"rate"`
+	Penalty float64 `json:"penalty"`
+}
+
+// anomalyReport is stored on the attempt under "sincerity_anomalies".
+type anomalyReport struct {
+	Answered       int           `json:"answered"`
+	Speeding       anomalySignal `json:"speeding"`
+	StraightLining anomalySignal `json:"straight_lining"`
+	Flipping       anomalySignal `json:"flipping"`
+	Penalty        float64       `json:"penalty"`
+}
+
+// detectAnomalies scores answers (in question order) against the policy.
+func detectAnomalies(answers []answerPattern, p anomalyPolicy) anomalyReport {
+	report := anomalyReport{Answered: len(answers)}
+	if len(answers) == 0 {
+		return report
+	}
+
+	var speeding, flipping int
+	for _, a := range answers {
+		if a.TimeSpentSeconds > 0 && float64(a.TimeSpentSeconds) < p.MinReadSeconds {
+			speeding++
+		}
+		if p.FlipThreshold > 0 && a.AnswerChangeCount >= p.FlipThreshold {
+			flipping++
+		}
+	}
+
+	report.Speeding = newAnomalySignal(speeding, len(answers), p.SpeedingWeight)
+	report.StraightLining = newAnomalySignal(straightLined(answers, p.StraightLineMinRun), len(answers), p.StraightLineWeight)
+	report.Flipping = newAnomalySignal(flipping, len(answers), p.FlipWeight)
+	report.Penalty = roundHundredths(report.Speeding.Penalty + report.StraightLining.Penalty + report.Flipping.Penalty)
+	return report
+}
+
+// straightLined counts the answers that sit in a run of at least minRun
+// consecutive answers on the same option position.
+func straightLined(answers []answerPattern, minRun int) int {
+	if minRun < 2 {
+		return 0
+	}
+	flagged, run := 0, 0
+	for i, a := range answers {
+		if a.Position > 0 && i > 0 && a.Position == answers[i-1].Position {
+			run++
+		} else if a.Position > 0 {
+			run = 1
+		} else {
+			run = 0
+		}
+		switch {
+		case run == minRun:
+			flagged += minRun
+		case run > minRun:
+			flagged++
+		}
+	}
+	return flagged
+}
+
+func newAnomalySignal(flagged, answered int, weight float64) anomalySignal {
+	rate := float64(flagged) / float64(answered)
+	return anomalySignal{
+		Flagged: flagged,
+		Rate:    roundHundredths(rate),
+		Penalty: roundHundredths(rate * weight),
+	}
+}
+
+func roundHundredths(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// optionPosition returns the 1-based on-screen position of the option with
+// display order chosen, given the row's question_options_order. Without a
+// stored order options are shown by display_order. The order is read by
+// parseOptionsOrder, as for the exam payload.
+func optionPosition(optionsOrder string, chosen int) int {
+	if chosen <= 0 {
+		return 0
+	}
+	for i, pos := range parseOptionsOrder(optionsOrder) {
+		if pos == chosen {
+			return i + 1
+		}
+	}
+	return chosen
+}
+
+// loadAnswerPatterns reads the answered questions of an attempt in order.
+// Multi-select answers keep their time and change count but have no
+// position, since main_option_id only holds one of their choices.
+func loadAnswerPatterns(db *gorm.DB, attemptID int64) ([]answerPattern, error) {
+	var rows []struct {
+		TimeSpentSeconds     int
+		AnswerChangeCount    int
+		QuestionOptionsOrder string
+		ChosenOrder          int
+	}
+	err := db.Raw(`
+		SELECT a.time_spent_seconds, a.answer_change_count,
+		       COALESCE(a.question_options_order, '') AS question_options_order,
+		       CASE WHEN a.is_multiple_selection THEN 0
+		            ELSE COALESCE(mo.display_order, oo.display_order, 0) END AS chosen_order
+		FROM assessment_answers a
+		LEFT JOIN assessment_question_options mo ON mo.id = a.main_option_id
+		LEFT JOIN open_question_options oo ON oo.id = a.open_option_id
+		WHERE a.assessment_attempt_id = ? AND a.status = 'ANSWERED'
+		ORDER BY a.question_sequence ASC`, attemptID).Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Stored orders are only on screen while shuffling is on.
+	shuffle := shuffleOptionsEnabled(db)
+	patterns := make([]answerPattern, 0, len(rows))
+	for _, r := range rows {
+		if !shuffle {
+			r.QuestionOptionsOrder = ""
+		}
+		patterns = append(patterns, answerPattern{
+			TimeSpentSeconds:  r.TimeSpentSeconds,
+			AnswerChangeCount: r.AnswerChangeCount,
+			Position:          optionPosition(r.QuestionOptionsOrder, r.ChosenOrder),
+		})
+	}
+	return patterns, nil
+}
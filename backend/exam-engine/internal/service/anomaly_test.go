@@ -0,0 +1,94 @@
+package service
+
+import "testing"
+
+func TestDetectAnomalies(t *testing.T) {
+	p := anomalyPolicy{
+		MinReadSeconds:     3,
+		SpeedingWeight:     30,
+		StraightLineMinRun: 4,
+		StraightLineWeight: 20,
+		FlipThreshold:      3,
+		FlipWeight:         10,
+	}
+	answer := func(seconds, changes, position int) answerPattern {
+		return answerPattern{TimeSpentSeconds: seconds, AnswerChangeCount: changes, Position: position}
+	}
+
+	t.Run("clean", func(t *testing.T) {
+		got := detectAnomalies([]answerPattern{answer(10, 0, 1), answer(8, 1, 2), answer(12, 0, 3), answer(9, 0, 1)}, p)
+		if got.Penalty != 0 {
+			t.Errorf("penalty = %v, want 0 (%+v)", got.Penalty, got)
+		}
+	})
+
+	t.Run("untimed answers are not speeding", func(t *testing.T) {
+		got := detectAnomalies([]answerPattern{answer(0, 0, 1), answer(0, 0, 2)}, p)
+		if got.Speeding.Flagged != 0 {
+			t.Errorf("speeding flagged = %d, want 0", got.Speeding.Flagged)
+		}
+	})
+
+	t.Run("speeding and flipping", func(t *testing.T) {
+		got := detectAnomalies([]answerPattern{answer(1, 0, 1), answer(2, 4, 2), answer(5, 3, 3), answer(6, 0, 4)}, p)
+		if got.Speeding.Flagged != 2 || got.Speeding.Penalty != 15 {
+			t.Errorf("speeding = %+v, want 2 flagged, 15 points", got.Speeding)
+		}
+		if got.Flipping.Flagged != 2 || got.Flipping.Penalty != 5 {
+			t.Errorf("flipping = %+v, want 2 flagged, 5 points", got.Flipping)
+		}
+		if got.Penalty != 20 {
+			t.Errorf("penalty = %v, want 20", got.Penalty)
+		}
+	})
+
+	t.Run("straight-lining", func(t *testing.T) {
+		var answers []answerPattern
+		for _, pos := range []int{2, 2, 2, 2, 2, 1, 3, 3, 3, 0, 3} {
+			answers = append(answers, answer(10, 0, pos))
+		}
+		got := detectAnomalies(answers, p)
+		if got.StraightLining.Flagged != 5 {
+			t.Errorf("straight-lined = %d, want 5", got.StraightLining.Flagged)
+		}
+		if want := roundHundredths(5.0 / 11 * 20); got.StraightLining.Penalty != want {
+			t.Errorf("penalty = %v, want %v", got.StraightLining.Penalty, want)
+		}
+	})
+
+	t.Run("no answers", func(t *testing.T) {
+		if got := detectAnomalies(nil, p); got.Penalty != 0 || got.Answered != 0 {
+			t.Errorf("got %+v", got)
+		}
+	})
+
+	t.Run("default weights record but do not penalise", func(t *testing.T) {
+		got := detectAnomalies([]answerPattern{answer(1, 4, 1), answer(1, 4, 1)}, defaultAnomalyPolicy)
+		if got.Speeding.Flagged != 2 || got.Flipping.Flagged != 2 {
+			t.Errorf("signals = %+v, want both answers flagged", got)
+		}
+		if got.Penalty != 0 {
+			t.Errorf("penalty = %v, want 0", got.Penalty)
+		}
+	})
+}
+
+func TestOptionPosition(t *testing.T) {
+	cases := []struct {
+		order  string
+		chosen int
+		want   int
+	}{
+		{"[3,1,4,2]", 4, 3},
+		{"[3,1,4,2]", 3, 1},
+		{"3, 1, 4, 2", 4, 3},
+		{"", 2, 2},
+		{"not json", 2, 2},
+		{"[3,1,4,2]", 0, 0},
+	}
+	for _, c := range cases {
+		if got := optionPosition(c.order, c.chosen); got != c.want {
+			t.Errorf("optionPosition(%q, %d) = %d, want %d", c.order, c.chosen, got, c.want)
+		}
+	}
+}
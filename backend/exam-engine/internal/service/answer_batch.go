@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"exam-engine/internal/models"
+	"exam-engine/internal/repository"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Batch item outcomes.
+const (
+	batchItemSaved     = "SAVED"
+	batchItemDuplicate = "DUPLICATE"
+	batchItemRejected  = "REJECTED"
+)
+
+// SubmitAnswerBatch applies a client's queued answers for one attempt in a
+// single transaction. Each item runs in its own savepoint, so a bad item is
+// rejected without losing the others, and the completion pipeline runs at
+// most once, after the whole batch is committed. When the attempt can no
+// longer be answered (completed, expired, out of time, another device),
+// retried items still come back as DUPLICATE and the rest are rejected.
+func (s *ExamService) SubmitAnswerBatch(req models.BatchAnswerRequest, caller Caller) (*models.BatchAnswerResponse, error) {
+	db := repository.GetDB()
+
+	resp := &models.BatchAnswerResponse{
+		AttemptID: req.AttemptID,
+		Results:   make([]models.BatchAnswerResult, 0, len(req.Answers)),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		attempt, err := s.loadOwnedAttempt(tx, req.AttemptID, caller)
+		if err != nil {
+			return err
+		}
+		// An attempt that can no longer be answered still reports retried
+		// items as duplicates; every other item is rejected with the reason.
+		notAnswerable := s.checkAnswerable(tx, attempt, caller)
+		if notAnswerable == nil {
+			s.ensureAttemptInProgress(tx, attempt)
+		}
+
+		for i, item := range req.Answers {
+			if item.AttemptID == 0 {
+				item.AttemptID = req.AttemptID
+			}
+			result := models.BatchAnswerResult{Index: i, QuestionID: item.QuestionID}
+			outcome := ""
+
+			itemErr := tx.Transaction(func(sp *gorm.DB) error {
+				if item.AttemptID != req.AttemptID {
+					return &ExamError{Code: ErrCodeQuestionNotFound, Message: "answer belongs to a different attempt"}
+				}
+				answerRecord, err := findAnswerRecord(sp, item)
+				if err != nil {
+					return err
+				}
+				if answerRecord.AssessmentAttemptID != req.AttemptID {
+					return &ExamError{Code: ErrCodeQuestionNotFound, Message: "question not found for this attempt"}
+				}
+				result.AssessmentAnswerID = answerRecord.ID
+				if notAnswerable != nil {
+					if !isDuplicateSubmission(answerRecord, item) {
+						return notAnswerable
+					}
+					outcome = answerDuplicate
+					return nil
+				}
+				outcome, err = s.applyAnswer(sp, answerRecord, item)
+				return err
+			})
+
+			if itemErr != nil {
+				result.Status = batchItemRejected
+				result.Message = itemErr.Error()
+				var examErr *ExamError
+				if errors.As(itemErr, &examErr) {
+					result.Code = examErr.Code
+				}
+				resp.Rejected++
+			} else if outcome == answerDuplicate {
+				result.Status = batchItemDuplicate
+				resp.Duplicates++
+			} else {
+				result.Status = batchItemSaved
+				resp.Saved++
+			}
+			resp.Results = append(resp.Results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Saved > 0 {
+		completed, err := s.completeIfFullyAnswered(db, req.AttemptID)
+		if err != nil {
+			return nil, err
+		}
+		resp.AttemptCompleted = completed
+	}
+
+	fmt.Printf("[SubmitAnswerBatch] Attempt %d: %d saved, %d duplicates, %d rejected, completed=%v\n",
+		req.AttemptID, resp.Saved, resp.Duplicates, resp.Rejected, resp.AttemptCompleted)
+	return resp, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"fmt"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// batchFixture is an IN_PROGRESS attempt of user 7 with one single-select
+// question per answer row.
+type batchFixture struct {
+	attempt   models.AssessmentAttempt
+	questions []int64
+	options   []int64
+	answers   []int64
+}
+
+func seedBatchAttempt(t *testing.T, db *gorm.DB, questions int) batchFixture {
+	t.Helper()
+	f := batchFixture{attempt: models.AssessmentAttempt{UserID: 7, Status: "IN_PROGRESS"}}
+	mustCreate(t, db, &f.attempt)
+	for i := 0; i < questions; i++ {
+		question := models.AssessmentQuestion{SetNumber: 1, Metadata: "{}"}
+		mustCreate(t, db, &question)
+		option := models.AssessmentQuestionOption{QuestionID: question.ID, ScoreValue: 2, Metadata: "{}"}
+		mustCreate(t, db, &option)
+		answer := models.AssessmentAnswer{
+			AssessmentAttemptID: f.attempt.ID,
+			QuestionSource:      "MAIN",
+			MainQuestionID:      &question.ID,
+			QuestionSequence:    i + 1,
+			Metadata:            "{}",
+		}
+		mustCreate(t, db, &answer)
+		f.questions = append(f.questions, question.ID)
+		f.options = append(f.options, option.ID)
+		f.answers = append(f.answers, answer.ID)
+	}
+	return f
+}
+
+func (f batchFixture) item(i int, key string) models.StudentAnswer {
+	return models.StudentAnswer{
+		AttemptID:      f.attempt.ID,
+		QuestionID:     f.questions[i],
+		QuestionSource: "MAIN",
+		SelectedOption: f.options[i],
+		IdempotencyKey: key,
+	}
+}
+
+// countingCompletions replaces the completion pipeline with a counter.
+func countingCompletions(s *ExamService) *int {
+	calls := 0
+	s.complete = func(db *gorm.DB, attemptID int64, mode string) (*completionResult, error) {
+		calls++
+		return &completionResult{}, nil
+	}
+	return &calls
+}
+
+func TestSubmitAnswerBatchStatuses(t *testing.T) {
+	db := newTestDB(t)
+	f := seedBatchAttempt(t, db, 4)
+	s := NewExamService()
+	completions := countingCompletions(s)
+
+	// Question 3 was already answered at client_seq 5.
+	db.Model(&models.AssessmentAnswer{}).Where("id = ?", f.answers[2]).
+		Update("metadata", `{"submission": {"client_seq": 5}}`)
+
+	stale := f.item(2, "k3")
+	stale.ClientSeq = 3
+	foreign := f.item(0, "k5")
+	foreign.AttemptID = f.attempt.ID + 1
+
+	resp, err := s.SubmitAnswerBatch(models.BatchAnswerRequest{
+		AttemptID: f.attempt.ID,
+		Answers:   []models.StudentAnswer{f.item(0, "k1"), f.item(1, "k2"), stale, f.item(0, "k1"), foreign},
+	}, Caller{UserID: 7})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+
+	want := []struct{ status, code string }{
+		{batchItemSaved, ""},
+		{batchItemSaved, ""},
+		{batchItemRejected, ErrCodeStaleAnswer},
+		{batchItemDuplicate, ""},
+		{batchItemRejected, ErrCodeQuestionNotFound},
+	}
+	if len(resp.Results) != len(want) {
+		t.Fatalf("results = %+v", resp.Results)
+	}
+	for i, w := range want {
+		if got := resp.Results[i]; got.Index != i || got.Status != w.status || got.Code != w.code {
+			t.Errorf("item %d = %+v, want %s %s", i, got, w.status, w.code)
+		}
+	}
+	if resp.Saved != 2 || resp.Duplicates != 1 || resp.Rejected != 2 {
+		t.Errorf("counts = %d saved, %d duplicates, %d rejected", resp.Saved, resp.Duplicates, resp.Rejected)
+	}
+	// Question 4 is unanswered, so nothing completes.
+	if resp.AttemptCompleted || *completions != 0 {
+		t.Errorf("completed = %v after %d completions, want none", resp.AttemptCompleted, *completions)
+	}
+}
+
+func TestSubmitAnswerBatchRollsBackOnlyTheFailedItem(t *testing.T) {
+	db := newTestDB(t)
+	f := seedBatchAttempt(t, db, 3)
+	s := NewExamService()
+	countingCompletions(s)
+
+	// Every answer write is audited; the write of the second answer then
+	// fails without undoing itself (FAIL keeps the statement's changes), so
+	// only its savepoint can take the update and the audit row back.
+	for _, ddl := range []string{
+		`CREATE TABLE answer_audit (answer_id INTEGER)`,
+		fmt.Sprintf(`CREATE TRIGGER audit_answers AFTER UPDATE ON assessment_answers BEGIN
+			INSERT INTO answer_audit (answer_id) VALUES (NEW.id);
+			SELECT RAISE(FAIL, 'write refused') WHERE NEW.id = %d;
+		END`, f.answers[1]),
+	} {
+		if err := db.Exec(ddl).Error; err != nil {
+			t.Fatalf("%s: %v", ddl, err)
+		}
+	}
+
+	resp, err := s.SubmitAnswerBatch(models.BatchAnswerRequest{
+		AttemptID: f.attempt.ID,
+		Answers:   []models.StudentAnswer{f.item(0, "k1"), f.item(1, "k2"), f.item(2, "k3")},
+	}, Caller{UserID: 7})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if resp.Saved != 2 || resp.Rejected != 1 || resp.Results[1].Status != batchItemRejected {
+		t.Fatalf("results = %+v", resp.Results)
+	}
+
+	var answers []models.AssessmentAnswer
+	db.Order("id").Find(&answers, f.answers)
+	for i, want := range []string{"ANSWERED", "NOT_ANSWERED", "ANSWERED"} {
+		if answers[i].Status != want {
+			t.Errorf("answer %d status = %s, want %s", i, answers[i].Status, want)
+		}
+	}
+	var audited []int64
+	db.Raw(`SELECT answer_id FROM answer_audit ORDER BY answer_id`).Scan(&audited)
+	if len(audited) != 2 || audited[0] != f.answers[0] || audited[1] != f.answers[2] {
+		t.Errorf("audited = %v, want only answers %d and %d", audited, f.answers[0], f.answers[2])
+	}
+}
+
+func TestSubmitAnswerBatchCompletesOnce(t *testing.T) {
+	db := newTestDB(t)
+	f := seedBatchAttempt(t, db, 3)
+	s := NewExamService()
+	completions := countingCompletions(s)
+
+	resp, err := s.SubmitAnswerBatch(models.BatchAnswerRequest{
+		AttemptID: f.attempt.ID,
+		Answers:   []models.StudentAnswer{f.item(0, "k1"), f.item(1, "k2"), f.item(2, "k3"), f.item(2, "k4")},
+	}, Caller{UserID: 7})
+	if err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if !resp.AttemptCompleted || *completions != 1 {
+		t.Errorf("completed = %v after %d completions, want exactly one", resp.AttemptCompleted, *completions)
+	}
+
+	// A replay of the whole batch saves nothing and completes nothing.
+	resp, err = s.SubmitAnswerBatch(models.BatchAnswerRequest{
+		AttemptID: f.attempt.ID,
+		Answers:   []models.StudentAnswer{f.item(0, "k1"), f.item(1, "k2"), f.item(2, "k3")},
+	}, Caller{UserID: 7})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if resp.Duplicates != 3 || resp.AttemptCompleted || *completions != 1 {
+		t.Errorf("replay = %+v after %d completions", resp, *completions)
+	}
+}
+
+func TestSubmitAnswerBatchReplayAfterCompletion(t *testing.T) {
+	db := newTestDB(t)
+	f := seedBatchAttempt(t, db, 2)
+	s := NewExamService()
+	completions := countingCompletions(s)
+
+	batch := models.BatchAnswerRequest{
+		AttemptID: f.attempt.ID,
+		Answers:   []models.StudentAnswer{f.item(0, "k1"), f.item(1, "k2")},
+	}
+	if _, err := s.SubmitAnswerBatch(batch, Caller{UserID: 7}); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	db.Model(&models.AssessmentAttempt{}).Where("id = ?", f.attempt.ID).Update("status", "COMPLETED")
+
+	batch.Answers = append(batch.Answers, f.item(1, "k3"))
+	resp, err := s.SubmitAnswerBatch(batch, Caller{UserID: 7})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if resp.Duplicates != 2 || resp.Rejected != 1 || resp.Results[2].Code != ErrCodeAlreadyCompleted {
+		t.Errorf("replay = %+v", resp.Results)
+	}
+	if *completions != 1 {
+		t.Errorf("completions = %d, want 1", *completions)
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// applyMainMultiSelect records a multi-select answer on a MAIN question. The
+// first pick stays in main_option_id for existing readers; the full list goes
+// to the answer metadata under selected_option_ids.
+func applyMainMultiSelect(tx *gorm.DB, answerRecord *models.AssessmentAnswer, answerMeta map[string]interface{}, question models.AssessmentQuestion, qMeta questionMeta, selected []int64) error {
+	var options []models.AssessmentQuestionOption
+	if err := tx.Where("question_id = ? AND is_deleted = false", question.ID).Find(&options).Error; err != nil {
+		return err
+	}
+
+	scored := make([]scoredOption, 0, len(options))
+	for _, opt := range options {
+		scored = append(scored, scoredOption{ID: opt.ID, Score: opt.ScoreValue, Correct: opt.IsCorrect})
+	}
+	if err := checkSelectionBelongs(scored, selected); err != nil {
+		return err
+	}
+
+	answerRecord.MainOptionID = &selected[0]
+	answerRecord.AnswerScore = scoreMultiSelect(qMeta.ScoringRule, scored, selected)
+	answerMeta["selected_option_ids"] = selected
+
+	applySincerityFlags(answerRecord, question, options, selected, true)
+	return nil
+}
+
+// applyMainSingleSelect records a single-select answer on a MAIN question.
+// The option must be a live option of that question.
+func applyMainSingleSelect(tx *gorm.DB, answerRecord *models.AssessmentAnswer, question models.AssessmentQuestion, optionID int64) error {
+	var options []models.AssessmentQuestionOption
+	if err := tx.Where("question_id = ? AND is_deleted = false", question.ID).Find(&options).Error; err != nil {
+		return err
+	}
+
+	var option *models.AssessmentQuestionOption
+	for i := range options {
+		if options[i].ID == optionID {
+			option = &options[i]
+		}
+	}
+	if option == nil {
+		return invalidAnswer(fmt.Sprintf("option %d does not belong to this question", optionID))
+	}
+
+	answerRecord.MainOptionID = &optionID
+	answerRecord.AnswerScore = option.ScoreValue
+	applySincerityFlags(answerRecord, question, options, []int64{optionID}, false)
+	return nil
+}
+
+// applyOpenMultiSelect records a multi-select answer on an OPEN question.
+// Valid options count as correct picks worth one point each.
+func applyOpenMultiSelect(tx *gorm.DB, answerRecord *models.AssessmentAnswer, answerMeta map[string]interface{}, question models.OpenQuestion, qMeta questionMeta, selected []int64) error {
+	var options []models.OpenQuestionOption
+	if err := tx.Where("open_question_id = ? AND is_deleted = false", question.ID).Find(&options).Error; err != nil {
+		return err
+	}
+
+	scored := make([]scoredOption, 0, len(options))
+	for _, opt := range options {
+		item := scoredOption{ID: opt.ID, Correct: opt.IsValid}
+		if opt.IsValid {
+			item.Score = 1
+		}
+		scored = append(scored, item)
+	}
+	if err := checkSelectionBelongs(scored, selected); err != nil {
+		return err
+	}
+
+	answerRecord.OpenOptionID = &selected[0]
+	answerRecord.AnswerScore = scoreMultiSelect(qMeta.ScoringRule, scored, selected)
+	answerMeta["selected_option_ids"] = selected
+	answerMeta["correct"] = selectionIsExactlyCorrect(scored, selected)
+	return nil
+}
+
+// checkSelectionBelongs rejects picks that are not options of the question.
+func checkSelectionBelongs(options []scoredOption, selected []int64) error {
+	known := make(map[int64]bool, len(options))
+	for _, opt := range options {
+		known[opt.ID] = true
+	}
+	for _, id := range selected {
+		if !known[id] {
+			return invalidAnswer(fmt.Sprintf("option %d does not belong to this question", id))
+		}
+	}
+	return nil
+}
+
+// storedSelectedOptions reads the selected_option_ids list written for a
+// multi-select answer.
+func storedSelectedOptions(rawMetadata string) []int64 {
+	var meta struct {
+		SelectedOptionIDs []int64 `json:"selected_option_ids"`
+	}
+	if rawMetadata == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(rawMetadata), &meta); err != nil {
+		return nil
+	}
+	return meta.SelectedOptionIDs
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"fmt"
+)
+
+// Multi-select scoring rules (questionMeta.ScoringRule).
+const (
+	// scoringRuleSum adds the score of every selected option (default).
+	scoringRuleSum = "sum"
+	// scoringRuleAllOrNothing gives full credit only when exactly the correct
+	// options are selected.
+	scoringRuleAllOrNothing = "all_or_nothing"
+	// scoringRulePartial gives full credit scaled by (correct picks - wrong
+	// picks) / number of correct options, never below zero.
+	scoringRulePartial = "partial"
+)
+
+// scoredOption is the scoring view of an option: its score_value (or 1 for a
+// valid open option) and whether it is a correct pick.
+type scoredOption struct {
+	ID      int64
+	Score   float64
+	Correct bool
+}
+
+// selectedOptionIDs normalises the selection of an answer: `selected_options`
+// when given, otherwise the legacy single `selected_option`. Duplicates are
+// dropped and the count is checked against the question's rules.
+func selectedOptionIDs(req models.StudentAnswer, meta questionMeta) ([]int64, error) {
+	ids := req.SelectedOptions
+	if len(ids) == 0 && req.SelectedOption > 0 {
+		ids = []int64{req.SelectedOption}
+	}
+
+	seen := make(map[int64]bool, len(ids))
+	selected := make([]int64, 0, len(ids))
+	for _, id := range ids {
+		if id > 0 && !seen[id] {
+			seen[id] = true
+			selected = append(selected, id)
+		}
+	}
+
+	switch {
+	case len(selected) == 0:
+		return nil, invalidAnswer("select an option")
+	case !meta.MultiSelect && len(selected) > 1:
+		return nil, invalidAnswer("this question accepts a single option")
+	case meta.MinSelections > 0 && len(selected) < meta.MinSelections:
+		return nil, invalidAnswer(fmt.Sprintf("select at least %d options", meta.MinSelections))
+	case meta.MaxSelections > 0 && len(selected) > meta.MaxSelections:
+		return nil, invalidAnswer(fmt.Sprintf("select at most %d options", meta.MaxSelections))
+	}
+	return selected, nil
+}
+
+// scoreMultiSelect scores a multi-select answer under `rule`. Full credit is
+// the summed score of the correct options, or 1 when they carry no score.
+func scoreMultiSelect(rule string, options []scoredOption, selected []int64) float64 {
+	picked := make(map[int64]bool, len(selected))
+	for _, id := range selected {
+		picked[id] = true
+	}
+
+	var sum, fullCredit float64
+	var correctTotal, correctPicked, wrongPicked int
+	for _, opt := range options {
+		if picked[opt.ID] {
+			sum += opt.Score
+		}
+		if opt.Correct {
+			correctTotal++
+			fullCredit += opt.Score
+			if picked[opt.ID] {
+				correctPicked++
+			}
+		} else if picked[opt.ID] {
+			wrongPicked++
+		}
+	}
+	if fullCredit == 0 {
+		fullCredit = 1
+	}
+
+	switch rule {
+	case scoringRuleAllOrNothing:
+		if correctTotal > 0 && correctPicked == correctTotal && wrongPicked == 0 {
+			return fullCredit
+		}
+		return 0
+	case scoringRulePartial:
+		if correctTotal == 0 {
+			return 0
+		}
+		ratio := float64(correctPicked-wrongPicked) / float64(correctTotal)
+		if ratio < 0 {
+			ratio = 0
+		}
+		return fullCredit * ratio
+	default:
+		return sum
+	}
+}
+
+// selectionIsExactlyCorrect reports whether the picks are exactly the
+// correct options.
+func selectionIsExactlyCorrect(options []scoredOption, selected []int64) bool {
+	return scoreMultiSelect(scoringRuleAllOrNothing, options, selected) > 0
+}
+
+func invalidAnswer(message string) *ExamError {
+	return &ExamError{Code: ErrCodeInvalidAnswer, Message: message}
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"errors"
+	"exam-engine/internal/models"
+	"reflect"
+	"testing"
+)
+
+func TestSelectedOptionIDs(t *testing.T) {
+	multi := questionMeta{MultiSelect: true, MinSelections: 2, MaxSelections: 3}
+	cases := []struct {
+		name string
+		req  models.StudentAnswer
+		meta questionMeta
+		want []int64
+		fail bool
+	}{
+		{"legacy single option", models.StudentAnswer{SelectedOption: 5}, questionMeta{}, []int64{5}, false},
+		{"list wins over single", models.StudentAnswer{SelectedOption: 5, SelectedOptions: []int64{7}}, questionMeta{}, []int64{7}, false},
+		{"nothing selected", models.StudentAnswer{}, questionMeta{}, nil, true},
+		{"several on single-select", models.StudentAnswer{SelectedOptions: []int64{1, 2}}, questionMeta{}, nil, true},
+		{"duplicates dropped", models.StudentAnswer{SelectedOptions: []int64{1, 2, 1}}, multi, []int64{1, 2}, false},
+		{"below minimum", models.StudentAnswer{SelectedOptions: []int64{1}}, multi, nil, true},
+		{"above maximum", models.StudentAnswer{SelectedOptions: []int64{1, 2, 3, 4}}, multi, nil, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := selectedOptionIDs(c.req, c.meta)
+			if c.fail {
+				var examErr *ExamError
+				if !errors.As(err, &examErr) || examErr.Code != ErrCodeInvalidAnswer {
+					t.Fatalf("err = %v, want %s", err, ErrCodeInvalidAnswer)
+				}
+				return
+			}
+			if err != nil || !reflect.DeepEqual(got, c.want) {
+				t.Errorf("selectedOptionIDs = %v, %v; want %v", got, err, c.want)
+			}
+		})
+	}
+}
+
+func TestScoreMultiSelect(t *testing.T) {
+	options := []scoredOption{
+		{ID: 1, Score: 2, Correct: true},
+		{ID: 2, Score: 2, Correct: true},
+		{ID: 3, Score: 1},
+		{ID: 4, Score: 0},
+	}
+	cases := []struct {
+		rule     string
+		selected []int64
+		want     float64
+	}{
+		{scoringRuleSum, []int64{1, 3}, 3},
+		{"", []int64{2, 4}, 2},
+		{scoringRuleAllOrNothing, []int64{1, 2}, 4},
+		{scoringRuleAllOrNothing, []int64{1, 2, 3}, 0},
+		{scoringRuleAllOrNothing, []int64{1}, 0},
+		{scoringRulePartial, []int64{1}, 2},
+		{scoringRulePartial, []int64{1, 2, 3}, 2},
+		{scoringRulePartial, []int64{3, 4}, 0},
+	}
+	for _, c := range cases {
+		if got := scoreMultiSelect(c.rule, options, c.selected); got != c.want {
+			t.Errorf("scoreMultiSelect(%q, %v) = %v, want %v", c.rule, c.selected, got, c.want)
+		}
+	}
+
+	unscored := []scoredOption{{ID: 1, Correct: true}, {ID: 2}}
+	if got := scoreMultiSelect(scoringRuleAllOrNothing, unscored, []int64{1}); got != 1 {
+		t.Errorf("unscored correct options should be worth 1, got %v", got)
+	}
+}
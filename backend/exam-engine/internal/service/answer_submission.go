@@ -0,0 +1,99 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"time"
+)
+
+// Answer submission outcomes.
+const (
+	answerApplied   = "APPLIED"
+	answerDuplicate = "DUPLICATE"
+	answerStale     = "STALE"
+)
+
+// maxRememberedKeys bounds how many idempotency keys are kept per answer row.
+// Retries arrive within seconds, so a short window is enough.
+const maxRememberedKeys = 20
+
+// answerSubmissionMeta is stored under "submission" in
+// assessment_answers.metadata and lets retried or reordered requests be
+// recognised.
+type answerSubmissionMeta struct {
+	RecentKeys []string   `json:"recent_keys,omitempty"`
+	ClientSeq  int64      `json:"client_seq,omitempty"`
+	ClientTs   *time.Time `json:"client_ts,omitempty"`
+}
+
+// classifySubmission decides what to do with an incoming answer given what was
+// last applied to the row:
+//   - DUPLICATE when its idempotency key was already applied (a retry),
+//   - STALE when it is older than the last applied write (client_seq first,
+//     client_ts when either side has no sequence),
+//   - APPLIED otherwise. Requests without ordering data are always applied.
+func classifySubmission(prev answerSubmissionMeta, req models.StudentAnswer) string {
+	if req.IdempotencyKey != "" {
+		for _, key := range prev.RecentKeys {
+			if key == req.IdempotencyKey {
+				return answerDuplicate
+			}
+		}
+	}
+
+	if req.ClientSeq > 0 && prev.ClientSeq > 0 {
+		if req.ClientSeq <= prev.ClientSeq {
+			return answerStale
+		}
+		return answerApplied
+	}
+	if req.ClientTimestamp != nil && prev.ClientTs != nil && !req.ClientTimestamp.After(*prev.ClientTs) {
+		return answerStale
+	}
+	return answerApplied
+}
+
+// isDuplicateSubmission reports whether req is a retry of an answer already
+// applied to the (locked) answer row.
+func isDuplicateSubmission(answerRecord *models.AssessmentAnswer, req models.StudentAnswer) bool {
+	_, submission := decodeAnswerMetadata(answerRecord.Metadata)
+	return classifySubmission(submission, req) == answerDuplicate
+}
+
+// recordSubmission returns prev updated with an applied request.
+func recordSubmission(prev answerSubmissionMeta, req models.StudentAnswer) answerSubmissionMeta {
+	next := prev
+	if req.IdempotencyKey != "" {
+		next.RecentKeys = append(append([]string{}, prev.RecentKeys...), req.IdempotencyKey)
+		if len(next.RecentKeys) > maxRememberedKeys {
+			next.RecentKeys = next.RecentKeys[len(next.RecentKeys)-maxRememberedKeys:]
+		}
+	}
+	if req.ClientSeq > 0 {
+		next.ClientSeq = req.ClientSeq
+	}
+	if req.ClientTimestamp != nil {
+		ts := *req.ClientTimestamp
+		next.ClientTs = &ts
+	}
+	return next
+}
+
+// decodeAnswerMetadata parses assessment_answers.metadata and its
+// "submission" entry; malformed metadata is treated as empty.
+func decodeAnswerMetadata(raw string) (map[string]interface{}, answerSubmissionMeta) {
+	meta := map[string]interface{}{}
+	var submission answerSubmissionMeta
+	if raw == "" || raw == "{}" {
+		return meta, submission
+	}
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return map[string]interface{}{}, submission
+	}
+	if val, ok := meta["submission"]; ok {
+		if b, err := json.Marshal(val); err == nil {
+			_ = json.Unmarshal(b, &submission)
+		}
+	}
+	return meta, submission
+}
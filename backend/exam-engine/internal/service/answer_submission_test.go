@@ -0,0 +1,138 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"testing"
+	"time"
+)
+
+func TestClassifySubmission(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	t1 := t0.Add(time.Second)
+	prev := answerSubmissionMeta{RecentKeys: []string{"k1", "k2"}, ClientSeq: 5, ClientTs: &t0}
+
+	cases := []struct {
+		name string
+		prev answerSubmissionMeta
+		req  models.StudentAnswer
+		want string
+	}{
+		{"first write", answerSubmissionMeta{}, models.StudentAnswer{IdempotencyKey: "k1", ClientSeq: 1}, answerApplied},
+		{"retry of applied key", prev, models.StudentAnswer{IdempotencyKey: "k2", ClientSeq: 5}, answerDuplicate},
+		{"newer sequence", prev, models.StudentAnswer{IdempotencyKey: "k3", ClientSeq: 6}, answerApplied},
+		{"older sequence", prev, models.StudentAnswer{IdempotencyKey: "k3", ClientSeq: 4}, answerStale},
+		{"same sequence, new key", prev, models.StudentAnswer{IdempotencyKey: "k3", ClientSeq: 5}, answerStale},
+		{"timestamp only, newer", answerSubmissionMeta{ClientTs: &t0}, models.StudentAnswer{ClientTimestamp: &t1}, answerApplied},
+		{"timestamp only, older", answerSubmissionMeta{ClientTs: &t1}, models.StudentAnswer{ClientTimestamp: &t0}, answerStale},
+		{"legacy request without guards", prev, models.StudentAnswer{}, answerApplied},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := classifySubmission(c.prev, c.req); got != c.want {
+				t.Errorf("classifySubmission = %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRecordSubmissionBoundsKeys(t *testing.T) {
+	var meta answerSubmissionMeta
+	for i := 0; i < maxRememberedKeys+5; i++ {
+		meta = recordSubmission(meta, models.StudentAnswer{IdempotencyKey: string(rune('a' + i)), ClientSeq: int64(i + 1)})
+	}
+	if len(meta.RecentKeys) != maxRememberedKeys {
+		t.Fatalf("kept %d keys, want %d", len(meta.RecentKeys), maxRememberedKeys)
+	}
+	if meta.RecentKeys[0] != string(rune('a'+5)) || meta.ClientSeq != maxRememberedKeys+5 {
+		t.Errorf("unexpected state: first=%q seq=%d", meta.RecentKeys[0], meta.ClientSeq)
+	}
+}
+
+func TestSubmitAnswerReplayAfterCompletion(t *testing.T) {
+	db := newTestDB(t)
+	f := seedBatchAttempt(t, db, 2)
+	s := NewExamService()
+	completions := countingCompletions(s)
+
+	// The last answer completes the attempt; the response is lost.
+	if _, err := s.SubmitAnswer(f.item(0, "k1"), Caller{UserID: 7}); err != nil {
+		t.Fatalf("first answer: %v", err)
+	}
+	last := f.item(1, "k2")
+	if _, err := s.SubmitAnswer(last, Caller{UserID: 7}); err != nil {
+		t.Fatalf("last answer: %v", err)
+	}
+	if *completions != 1 {
+		t.Fatalf("completions = %d, want 1", *completions)
+	}
+	past := time.Now().Add(-time.Hour)
+	db.Model(&models.AssessmentAttempt{}).Where("id = ?", f.attempt.ID).
+		Updates(map[string]interface{}{"status": "COMPLETED", "completed_at": past, "must_finish_by": past})
+
+	got, err := s.SubmitAnswer(last, Caller{UserID: 7})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if got.Outcome != answerDuplicate || got.AssessmentAnswerID != f.answers[1] {
+		t.Errorf("replay = %+v, want DUPLICATE of answer %d", got, f.answers[1])
+	}
+	if *completions != 1 {
+		t.Errorf("replay ran completion again (%d)", *completions)
+	}
+
+	// New answers, and replays by someone else, are still refused.
+	_, err = s.SubmitAnswer(f.item(1, "k3"), Caller{UserID: 7})
+	wantExamError(t, err, ErrCodeAlreadyCompleted)
+	_, err = s.SubmitAnswer(last, Caller{UserID: 8})
+	wantExamError(t, err, ErrCodeAttemptNotFound)
+}
+
+func TestSubmitAnswerReplayAfterDeadline(t *testing.T) {
+	db := newTestDB(t)
+	f := seedBatchAttempt(t, db, 2)
+	s := NewExamService()
+	countingCompletions(s)
+
+	if _, err := s.SubmitAnswer(f.item(0, "k1"), Caller{UserID: 7}); err != nil {
+		t.Fatalf("answer: %v", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	db.Model(&models.AssessmentAttempt{}).Where("id = ?", f.attempt.ID).Update("must_finish_by", past)
+
+	got, err := s.SubmitAnswer(f.item(0, "k1"), Caller{UserID: 7})
+	if err != nil || got.Outcome != answerDuplicate {
+		t.Errorf("replay = %+v, %v, want DUPLICATE", got, err)
+	}
+	_, err = s.SubmitAnswer(f.item(1, "k2"), Caller{UserID: 7})
+	wantExamError(t, err, ErrCodeTimeUp)
+}
+
+func TestSubmitAnswerRejectsForeignOption(t *testing.T) {
+	db := newTestDB(t)
+	f := seedBatchAttempt(t, db, 2)
+	s := NewExamService()
+	countingCompletions(s)
+
+	// A high-scoring option of the second question, and one that is gone.
+	db.Model(&models.AssessmentQuestionOption{}).Where("id = ?", f.options[1]).Update("score_value", 9)
+	for _, optionID := range []int64{f.options[1], f.options[1] + 100} {
+		req := f.item(0, "")
+		req.SelectedOption = optionID
+		_, err := s.SubmitAnswer(req, Caller{UserID: 7})
+		wantExamError(t, err, ErrCodeInvalidAnswer)
+	}
+
+	var answer models.AssessmentAnswer
+	db.First(&answer, f.answers[0])
+	if answer.Status != "NOT_ANSWERED" || answer.MainOptionID != nil || answer.AnswerScore != 0 {
+		t.Errorf("answer = %s option %v score %v, want it untouched", answer.Status, answer.MainOptionID, answer.AnswerScore)
+	}
+
+	if _, err := s.SubmitAnswer(f.item(0, "k1"), Caller{UserID: 7}); err != nil {
+		t.Fatalf("own option: %v", err)
+	}
+	db.First(&answer, f.answers[0])
+	if answer.AnswerScore != 2 {
+		t.Errorf("score = %v, want 2", answer.AnswerScore)
+	}
+}
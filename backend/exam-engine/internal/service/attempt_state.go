@@ -0,0 +1,88 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"exam-engine/internal/repository"
+	"time"
+)
+
+// GetAttemptState returns where the candidate is in an attempt without any
+// side effects: no status flips, no deadline stamping, no question generation.
+// The device lock does not apply: the state is read-only and owner-scoped,
+// and a runner reloads it before it has a session (or after another device
+// took over) to decide whether to resume or offer a takeover.
+func (s *ExamService) GetAttemptState(attemptID int64, studentID int64) (*models.AttemptState, error) {
+	db := repository.GetDB()
+
+	var attempt models.AssessmentAttempt
+	if err := db.Where("id = ? AND user_id = ?", attemptID, studentID).First(&attempt).Error; err != nil {
+		return nil, &ExamError{Code: ErrCodeAttemptNotFound, Message: "assessment attempt not found or access denied"}
+	}
+
+	var answers []models.AssessmentAnswer
+	if err := db.Select("id, question_source, main_question_id, open_question_id, question_sequence, status, main_option_id, open_option_id, is_multiple_selection, metadata, answer_text, time_spent_seconds, answer_change_count").
+		Where("assessment_attempt_id = ?", attemptID).
+		Order("question_sequence ASC").
+		Find(&answers).Error; err != nil {
+		return nil, err
+	}
+
+	level, _ := s.attemptLevel(db, attempt)
+	isLast, _ := s.IsLastLevel(attemptID)
+
+	return buildAttemptState(attempt, level, answers, isLast, time.Now()), nil
+}
+
+// buildAttemptState assembles the snapshot from already-loaded rows.
+func buildAttemptState(attempt models.AssessmentAttempt, level models.AssessmentLevel, answers []models.AssessmentAnswer, isLast bool, now time.Time) *models.AttemptState {
+	state := &models.AttemptState{
+		AttemptID:             attempt.ID,
+		Status:                attempt.Status,
+		LevelNumber:           level.LevelNumber,
+		IsLastLevel:           isLast,
+		UnlockAt:              attempt.UnlockAt,
+		ExpiresAt:             attempt.ExpiresAt,
+		CompletedAt:           attempt.CompletedAt,
+		Timing:                attemptTiming(attempt, now),
+		TotalQuestions:        len(answers),
+		AnsweredQuestionIDs:   []int64{},
+		UnansweredQuestionIDs: []int64{},
+		Answers:               make([]models.AnswerState, 0, len(answers)),
+	}
+
+	for _, ans := range answers {
+		item := models.AnswerState{
+			AssessmentAnswerID: ans.ID,
+			QuestionSource:     ans.QuestionSource,
+			QuestionSequence:   ans.QuestionSequence,
+			Status:             ans.Status,
+			TimeSpentSeconds:   ans.TimeSpentSeconds,
+			AnswerChangeCount:  ans.AnswerChangeCount,
+			AnswerText:         ans.AnswerText,
+		}
+		if ans.MainQuestionID != nil {
+			item.QuestionID = *ans.MainQuestionID
+			item.SelectedOptionID = ans.MainOptionID
+		} else if ans.OpenQuestionID != nil {
+			item.QuestionID = *ans.OpenQuestionID
+			item.SelectedOptionID = ans.OpenOptionID
+		}
+		if ans.IsMultipleSelection {
+			item.SelectedOptionIDs = storedSelectedOptions(ans.Metadata)
+		}
+
+		if ans.Status == "ANSWERED" {
+			state.AnsweredCount++
+			state.AnsweredQuestionIDs = append(state.AnsweredQuestionIDs, item.QuestionID)
+		} else {
+			state.UnansweredQuestionIDs = append(state.UnansweredQuestionIDs, item.QuestionID)
+			if state.CurrentSequence == nil {
+				seq := ans.QuestionSequence
+				state.CurrentSequence = &seq
+			}
+		}
+		state.Answers = append(state.Answers, item)
+	}
+
+	return state
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildAttemptState(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	deadline := now.Add(5 * time.Minute)
+	attempt := models.AssessmentAttempt{ID: 9, Status: "IN_PROGRESS", MustFinishBy: &deadline}
+	answers := []models.AssessmentAnswer{
+		{ID: 1, QuestionSource: "MAIN", QuestionSequence: 1, Status: "ANSWERED", MainQuestionID: int64Ptr(100), MainOptionID: int64Ptr(1001), TimeSpentSeconds: 12},
+		{ID: 2, QuestionSource: "OPEN", QuestionSequence: 2, Status: "NOT_ANSWERED", OpenQuestionID: int64Ptr(200)},
+		{ID: 3, QuestionSource: "MAIN", QuestionSequence: 3, Status: "ANSWERED", MainQuestionID: int64Ptr(300), MainOptionID: int64Ptr(3003), AnswerChangeCount: 2},
+	}
+
+	state := buildAttemptState(attempt, models.AssessmentLevel{LevelNumber: 1}, answers, false, now)
+
+	if state.TotalQuestions != 3 || state.AnsweredCount != 2 {
+		t.Errorf("counts = %d/%d, want 2/3", state.AnsweredCount, state.TotalQuestions)
+	}
+	if !reflect.DeepEqual(state.AnsweredQuestionIDs, []int64{100, 300}) || !reflect.DeepEqual(state.UnansweredQuestionIDs, []int64{200}) {
+		t.Errorf("answered=%v unanswered=%v", state.AnsweredQuestionIDs, state.UnansweredQuestionIDs)
+	}
+	if state.CurrentSequence == nil || *state.CurrentSequence != 2 {
+		t.Errorf("current sequence = %v, want 2", state.CurrentSequence)
+	}
+	if sel := state.Answers[2].SelectedOptionID; sel == nil || *sel != 3003 {
+		t.Errorf("selection not restored: %v", sel)
+	}
+	if r := state.Timing.RemainingSeconds; r == nil || *r != 300 {
+		t.Errorf("remaining = %v, want 300", r)
+	}
+}
@@ -0,0 +1,644 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"exam-engine/internal/models"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Completion modes recorded on the attempt metadata as completion_mode.
+const (
+	completionAllAnswered = "ALL_ANSWERED"
+	completionManual      = "MANUAL_FINISH"
+	completionAutoSubmit  = "AUTO_SUBMIT"
+)
+
+// completionResult summarises what completeAttempt did, for the caller's
+// response and the student-service notification.
+type completionResult struct {
+	AlreadyCompleted bool
+	SessionCompleted bool
+	NextLevelNumber  int
+	UserID           int64
+	TotalQuestions   int64
+	AnsweredCount    int64
+}
+
+// recordCoverage stores how the attempt was completed and how much of it
+// was answered in the attempt metadata.
+func recordCoverage(metaMap map[string]interface{}, mode string, result *completionResult) {
+	metaMap["completion_mode"] = mode
+	metaMap["total_questions"] = result.TotalQuestions
+	metaMap["answered_count"] = result.AnsweredCount
+	metaMap["unanswered_count"] = result.TotalQuestions - result.AnsweredCount
+}
+
+// storedCoverage returns the coverage recorded when the attempt was
+// completed, counting the answer rows for attempts completed before it was
+// recorded.
+func storedCoverage(tx *gorm.DB, attempt models.AssessmentAttempt) (int64, int64) {
+	var meta struct {
+		TotalQuestions *int64 `json:"total_questions"`
+		AnsweredCount  *int64 `json:"answered_count"`
+	}
+	if attempt.Metadata != "" {
+		json.Unmarshal([]byte(attempt.Metadata), &meta)
+	}
+	if meta.TotalQuestions != nil && meta.AnsweredCount != nil {
+		return *meta.TotalQuestions, *meta.AnsweredCount
+	}
+	var total, answered int64
+	tx.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ?", attempt.ID).Count(&total)
+	tx.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ? AND status = ?", attempt.ID, "ANSWERED").Count(&answered)
+	return total, answered
+}
+
+// completeAttempt scores an attempt against whatever has been answered and
+// runs the rest of the completion pipeline: sincerity, the early Level 1
+// report, next-level unlock / Level 2 generation, session + report
+// finalisation and group status. The attempt row is locked for the whole
+// transaction, so concurrent callers (last answer, finish button, scheduler)
+// complete it exactly once; later calls report AlreadyCompleted with the
+// recorded coverage. Only IN_PROGRESS attempts are completed, and a manual
+// finish needs at least one answer.
+func (s *ExamService) completeAttempt(db *gorm.DB, attemptID int64, mode string) (*completionResult, error) {
+	result := &completionResult{}
+
+	// Start Transaction (Concurrency Fix)
+	err := db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// 1. Lock Attempt Row & Check Idempotency
+		var lockedAttempt models.AssessmentAttempt
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lockedAttempt, attemptID).Error; err != nil {
+			return err
+		}
+
+		if lockedAttempt.Status == "COMPLETED" {
+			fmt.Printf("[CompleteAttempt] IDEMPOTENCY HIT: Attempt %d already completed.\n", lockedAttempt.ID)
+			result.AlreadyCompleted = true
+			result.TotalQuestions, result.AnsweredCount = storedCoverage(tx, lockedAttempt)
+			return nil
+		}
+		// Checked under the lock: the scheduler may have expired the
+		// attempt since the caller looked at it.
+		if lockedAttempt.Status != "IN_PROGRESS" {
+			fmt.Printf("[CompleteAttempt] REJECTED: Attempt %d is %s\n", lockedAttempt.ID, lockedAttempt.Status)
+			return &ExamError{
+				Code:    ErrCodeNotInProgress,
+				Message: "only an attempt in progress can be completed",
+				Details: map[string]interface{}{"status": lockedAttempt.Status},
+			}
+		}
+
+		// Capture User ID for notification
+		result.UserID = lockedAttempt.UserID
+
+		// Coverage snapshot, taken under the lock so it matches what is scored.
+		tx.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ?", attemptID).Count(&result.TotalQuestions)
+		tx.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ? AND status = ?", attemptID, "ANSWERED").Count(&result.AnsweredCount)
+		if mode == completionManual && result.AnsweredCount == 0 {
+			return &ExamError{Code: ErrCodeNothingAnswered, Message: "answer at least one question before finishing"}
+		}
+
+		// Fetch Level Context First
+		levelID, err := attemptLevelID(tx, lockedAttempt)
+		if err != nil {
+			return err
+		}
+		var currentLevel models.AssessmentLevel
+		if err := tx.First(&currentLevel, "id = ?", levelID).Error; err != nil {
+			return err
+		}
+
+		// --- Scoring: whichever Scorer is registered for the level's pattern ---
+		scorer, err := scorerFor(currentLevel)
+		if err != nil {
+			fmt.Printf("[CompleteAttempt] ERROR: Attempt %d cannot be scored: %v\n", attemptID, err)
+			return err
+		}
+		scored, err := scorer.Score(tx, lockedAttempt, currentLevel)
+		if err != nil {
+			return fmt.Errorf("scoring attempt %d: %w", attemptID, err)
+		}
+		scoreMap := scored.Scores
+		if scoreMap == nil {
+			scoreMap = make(map[string]float64)
+		}
+		totalScore := scored.Total
+
+		// Find Dominant Trait ID
+		var traitID *int64
+		if scored.DominantCode != "" {
+			var trait models.PersonalityTrait
+			if err := tx.Where("code = ?", scored.DominantCode).First(&trait).Error; err == nil {
+				tID := trait.ID
+				traitID = &tID
+			}
+		}
+
+		// Add Total to Map
+		scoreMap["total"] = totalScore
+
+		// --- Sincerity Index Calculation ---
+		var sincerityStats struct {
+			AttentionFails     int64
+			DistractionsChosen int64
+			TotalQuestions     int64
+		}
+
+		tx.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ?", attemptID).
+			Select("COUNT(*) FILTER (WHERE is_attention_fail = true) as attention_fails, COUNT(*) FILTER (WHERE is_distraction_chosen = true) as distractions_chosen, COUNT(*) as total_questions").
+			Scan(&sincerityStats)
+
+		sincerityIn := sincerityInputs{
+			AttentionFails:     sincerityStats.AttentionFails,
+			DistractionsChosen: sincerityStats.DistractionsChosen,
+		}
+		// Proctoring events (tab switches, paste, ...) lower sincerity too.
+		var integrity *float64
+		if counters, err := proctoringCounters(tx, attemptID); err == nil && len(counters) > 0 {
+			score := integrityScore(counters)
+			integrity = &score
+			sincerityIn.IntegrityScore = integrity
+		}
+		// So do answer patterns: speeding, straight-lining, flipping.
+		policy := loadSincerityPolicy(lockedAttempt.ProgramID)
+		var anomalies *anomalyReport
+		if patterns, err := loadAnswerPatterns(tx, attemptID); err == nil {
+			report := detectAnomalies(patterns, policy.anomalies(currentLevel.LevelNumber))
+			anomalies = &report
+			sincerityIn.AnomalyPenalty = report.Penalty
+		} else {
+			fmt.Printf("[CompleteAttempt] Answer patterns unavailable for Attempt %d: %v\n", attemptID, err)
+		}
+		sincerityIndex, sincerityClass := computeSincerity(sincerityIn, policy)
+
+		// --- Metadata Update ---
+		// Re-use lockedAttempt for metadata as it's fresh
+		metaMap := make(map[string]interface{})
+		if lockedAttempt.Metadata != "" && lockedAttempt.Metadata != "{}" {
+			json.Unmarshal([]byte(lockedAttempt.Metadata), &metaMap)
+		}
+
+		metaMap["overall_sincerity"] = sincerityIndex // Always store sincerity
+		if integrity != nil {
+			metaMap["integrity_score"] = *integrity
+		}
+		if anomalies != nil {
+			metaMap["sincerity_anomalies"] = anomalies
+		}
+		// The exact policy used, so the class can be explained after the
+		// settings change.
+		metaMap["sincerity_policy_version"] = policy.Version
+		metaMap["sincerity_policy"] = policy
+		recordCoverage(metaMap, mode, result)
+
+		// Essays are scored by a reviewer later; flag the attempt so reports
+		// know the totals are provisional.
+		var pendingManual int64
+		tx.Model(&models.AssessmentAnswer{}).
+			Where("assessment_attempt_id = ? AND metadata->'response'->>'scoring_status' = ?", attemptID, scoringPendingManual).
+			Count(&pendingManual)
+		if pendingManual > 0 {
+			metaMap["pending_manual_scoring"] = pendingManual
+		} else {
+			delete(metaMap, "pending_manual_scoring")
+		}
+
+		metaMap[scorer.MetadataKey()] = scoreMap
+		for k, v := range scored.Extras {
+			metaMap[k] = v
+		}
+
+		// OPEN items (image/audio/video/document): correctness per
+		// question_type, whatever the level.
+		if rows, err := loadOpenTypeTotals(tx, attemptID); err != nil {
+			fmt.Printf("[CompleteAttempt] Open scores unavailable for Attempt %d: %v\n", attemptID, err)
+		} else if openScores := summariseOpenScores(rows); openScores != nil {
+			metaMap["open_scores"] = openScores
+		}
+
+		updatedMeta, _ := json.Marshal(metaMap)
+
+		// --- Update Current Attempt ---
+		updates := map[string]interface{}{
+			"status":          "COMPLETED",
+			"completed_at":    now,
+			"metadata":        string(updatedMeta),
+			"total_score":     totalScore,
+			"sincerity_index": sincerityIndex,
+			"sincerity_class": sincerityClass,
+		}
+		// Only update dominant_trait_id if it was calculated (Level 1)
+		if traitID != nil {
+			updates["dominant_trait_id"] = traitID
+		}
+
+		if err := tx.Model(&models.AssessmentAttempt{}).Where("id = ?", attemptID).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		// --- 🟢 ASSIGN REPORT NUMBER AT LEVEL 1 ---
+		// As soon as the DISC level (Level 1, Behavioural) completes, mint
+		// the OBI report number so the Level 1 report can be downloaded with
+		// a real reference while later levels are still pending. The level
+		// qualifies by the pattern it was scored as, not its number or name.
+		// The ACI / Level-3/4 score snapshot is backfilled when the whole
+		// session completes (see the completion block below). Idempotent:
+		// only creates if absent.
+		if levelPatternType(currentLevel) == "DISC" {
+			var existingReport models.AssessmentReports
+			if err := tx.Where("assessment_session_id = ?", lockedAttempt.AssessmentSessionID).First(&existingReport).Error; err != nil {
+				var repSession models.AssessmentSession
+				if err := tx.First(&repSession, lockedAttempt.AssessmentSessionID).Error; err == nil {
+					reportNumber := s.generateReportNumber(tx, repSession, now)
+					// Any level already scored (e.g. Level 3/4 taken first)
+					// goes into the snapshot too.
+					snapshot := collectReportScores(tx, repSession.ID)
+					earlyReport := models.AssessmentReports{
+						AssessmentSessionID: repSession.ID,
+						ReportNumber:        reportNumber,
+						GeneratedAt:         now,
+						DiscScores:          snapshot.Disc,
+						AgileScores:         snapshot.Agile,
+						Level3Scores:        snapshot.Level3,
+						Level4Scores:        snapshot.Level4,
+						OverallSincerity:    sincerityIndex,
+						DominantTraitID:     traitID,
+						Metadata:            snapshot.Metadata,
+					}
+					// Isolate the insert in a savepoint so a unique-number race
+					// (two students finishing Level 1 at the same instant) can
+					// never abort the whole answer-submission transaction - it
+					// just skips the early row and falls back to creating it at
+					// completion.
+					createErr := tx.Transaction(func(tx2 *gorm.DB) error {
+						return tx2.Create(&earlyReport).Error
+					})
+					if createErr != nil {
+						fmt.Printf("[CompleteAttempt] Skipped early L1 Assessment Report (will create at completion): %v\n", createErr)
+					} else {
+						fmt.Printf("[CompleteAttempt] Early L1 Assessment Report created: %s\n", reportNumber)
+					}
+				}
+			}
+		}
+
+		// 5. Next Level Setup (Level 2 Generation)
+		var nextLevel models.AssessmentLevel
+		var nextAttempt models.AssessmentAttempt
+		hasNextLevel := false
+
+		// Advance only through the levels this candidate was actually
+		// SCHEDULED for - i.e. the next mandatory level they already have an
+		// attempt for. We deliberately do NOT auto-create attempts for
+		// mandatory levels that weren't assigned to this registration (e.g.
+		// ACI when only Behavioural + IAT Gen + Metaphor were scheduled), so
+		// the exam and the report follow exactly the assigned set. A level
+		// enabled after registration must be added via re-assignment.
+		nextLevelErr := tx.
+			Table("assessment_levels").
+			Select("assessment_levels.*").
+			Joins("JOIN assessment_attempts aa ON aa.assessment_level_id = assessment_levels.id AND aa.assessment_session_id = ?", lockedAttempt.AssessmentSessionID).
+			Where("assessment_levels.level_number > ? AND assessment_levels.is_mandatory = ?", currentLevel.LevelNumber, true).
+			Order("assessment_levels.level_number ASC").
+			First(&nextLevel).Error
+
+		if nextLevelErr == nil {
+			// The join guarantees this candidate has an attempt for the
+			// level; load it so we can unlock it.
+			if attemptErr := tx.Where("assessment_session_id = ? AND assessment_level_id = ?", lockedAttempt.AssessmentSessionID, nextLevel.ID).First(&nextAttempt).Error; attemptErr != nil {
+				fmt.Printf("[CompleteAttempt] Next-level attempt lookup failed (level %d): %v\n", nextLevel.LevelNumber, attemptErr)
+			}
+
+			if nextAttempt.ID != 0 {
+
+				// CASE A: Next Level Exists AND User has (or now has) an attempt -> Unlock it
+				hasNextLevel = true
+
+				unlockAt := now.Add(time.Duration(nextLevel.UnlockAfterHours) * time.Hour)
+				startWindow := 72
+				if nextLevel.StartWithinHours > 0 {
+					startWindow = nextLevel.StartWithinHours
+				}
+				expiresAt := unlockAt.Add(time.Duration(startWindow) * time.Hour)
+
+				tx.Model(&nextAttempt).Updates(map[string]interface{}{
+					"unlock_at":  unlockAt,
+					"expires_at": expiresAt,
+				})
+
+				result.NextLevelNumber = int(nextLevel.LevelNumber)
+
+				// Generate Questions for Next Level (Trait Based for Level 2)
+				if nextLevel.LevelNumber == 2 && traitID != nil {
+					if s.isIATGenLevel2Attempt(tx, nextAttempt) {
+						fmt.Printf("[CompleteAttempt] Level 2 Attempt %d configured for IAT Gen. Skipping ACI generation.\n", nextAttempt.ID)
+						s.markAttemptAsIATGen(tx, nextAttempt.ID)
+						return nil
+					}
+
+					// 1. Fetch Session/Registration Metadata for board info
+					var session models.AssessmentSession
+					var studentBoard string = ""
+
+					if err := tx.First(&session, nextAttempt.AssessmentSessionID).Error; err == nil {
+						var meta map[string]interface{}
+						if session.Metadata != "" && session.Metadata != "{}" {
+							if err := json.Unmarshal([]byte(session.Metadata), &meta); err == nil {
+								if val, ok := meta["studentBoard"]; ok {
+									if v, ok := val.(string); ok {
+										studentBoard = v
+									}
+								}
+							}
+						}
+					}
+
+					// Fallback to Registration metadata for board
+					if studentBoard == "" {
+						var reg models.Registration
+						if err := tx.First(&reg, nextAttempt.RegistrationID).Error; err == nil {
+							var regMeta map[string]interface{}
+							if reg.Metadata != "" && reg.Metadata != "{}" {
+								if err := json.Unmarshal([]byte(reg.Metadata), &regMeta); err == nil {
+									if val, ok := regMeta["studentBoard"]; ok {
+										if v, ok := val.(string); ok {
+											studentBoard = v
+										}
+									}
+								}
+							}
+						}
+					}
+
+					// 2. Clear existing generic questions (if any)
+					tx.Exec("DELETE FROM assessment_answers WHERE assessment_attempt_id = ?", nextAttempt.ID)
+
+					// 3. Insert new questions based on Trait (NO set_number for Level 2, matching admin-service)
+					var program models.Program
+					tx.First(&program, nextAttempt.ProgramID)
+
+					var query string
+					var args []interface{}
+
+					if program.Code == "SCHOOL_STUDENT" && studentBoard != "" {
+						// Balanced Category Selection with Board Priority
+						query = `
+							INSERT INTO assessment_answers (
+								assessment_attempt_id, assessment_session_id, user_id, registration_id, program_id, assessment_level_id, 
+								main_question_id, question_source, status, question_sequence, created_at, updated_at
+							)
+							SELECT ?, ?, ?, ?, ?, ?, id, 'MAIN', 'NOT_ANSWERED', ROW_NUMBER() OVER (ORDER BY RANDOM()), NOW(), NOW()
+							FROM (
+								WITH CombinedQuestions AS (
+									SELECT id, category, 1 as priority
+									FROM assessment_questions 
+									WHERE assessment_level_id = ? AND personality_trait_id = ? AND board = ? AND is_active = true AND is_deleted = false
+									UNION ALL
+									SELECT id, category, 2 as priority
+									FROM assessment_questions 
+									WHERE assessment_level_id = ? AND personality_trait_id = ? AND is_active = true AND is_deleted = false
+								)
+								SELECT id, ROW_NUMBER() OVER (PARTITION BY UPPER(category) ORDER BY priority, RANDOM()) as rnk
+								FROM CombinedQuestions
+								WHERE UPPER(category) IN ('COMMITMENT', 'COURAGE', 'FOCUS', 'OPENNESS', 'RESPECT')
+							) t
+							WHERE rnk <= 5
+						`
+						args = []interface{}{
+							nextAttempt.ID, nextAttempt.AssessmentSessionID, nextAttempt.UserID, nextAttempt.RegistrationID, nextAttempt.ProgramID, nextLevel.ID,
+							nextLevel.ID, *traitID, studentBoard,
+							nextLevel.ID, *traitID,
+						}
+					} else {
+						// Balanced Category Selection (Generic Trait)
+						query = `
+							INSERT INTO assessment_answers (
+								assessment_attempt_id, assessment_session_id, user_id, registration_id, program_id, assessment_level_id, 
+								main_question_id, question_source, status, question_sequence, created_at, updated_at
+							)
+							SELECT ?, ?, ?, ?, ?, ?, id, 'MAIN', 'NOT_ANSWERED', ROW_NUMBER() OVER (ORDER BY RANDOM()), NOW(), NOW()
+							FROM (
+								SELECT id, ROW_NUMBER() OVER (PARTITION BY UPPER(category) ORDER BY RANDOM()) as rnk
+								FROM assessment_questions 
+								WHERE assessment_level_id = ? 
+								  AND personality_trait_id = ?
+								  AND is_active = true 
+								  AND is_deleted = false
+								  AND UPPER(category) IN ('COMMITMENT', 'COURAGE', 'FOCUS', 'OPENNESS', 'RESPECT')
+							) t
+							WHERE rnk <= 5
+						`
+						args = []interface{}{
+							nextAttempt.ID, nextAttempt.AssessmentSessionID, nextAttempt.UserID, nextAttempt.RegistrationID, nextAttempt.ProgramID, nextLevel.ID,
+							nextLevel.ID, *traitID,
+						}
+					}
+
+					fmt.Printf("[CompleteAttempt] Generating Balanced Level 2 Questions for Attempt %d. Trait=%d, Board=%s\n", nextAttempt.ID, *traitID, studentBoard)
+					genResult := tx.Exec(query, args...)
+
+					if genResult.Error != nil {
+						fmt.Printf("[CompleteAttempt] Level 2 Generation ERROR for Attempt %d: %v\n", nextAttempt.ID, genResult.Error)
+					} else {
+						fmt.Printf("[CompleteAttempt] Level 2 Generation: %d questions generated for Attempt %d\n", genResult.RowsAffected, nextAttempt.ID)
+						assignOptionOrders(tx, nextAttempt.ID)
+					}
+				}
+			}
+		}
+
+		// CASE B: No Next Level (System-wide) OR No Attempt for Next Level (Program-specific) -> Mark Completed
+		if !hasNextLevel {
+			result.SessionCompleted = true
+
+			// This session is FULLY COMPLETED
+			var session models.AssessmentSession
+			if err := tx.First(&session, lockedAttempt.AssessmentSessionID).Error; err == nil {
+				tx.Model(&session).Updates(map[string]interface{}{
+					"status":       "COMPLETED",
+					"completed_at": now,
+				})
+
+				// --- 🟢 GENERATE ASSESSMENT REPORT ---
+				var existingReport models.AssessmentReports
+				if err := tx.Where("assessment_session_id = ?", session.ID).First(&existingReport).Error; err != nil {
+					// Report does not exist, create it
+
+					// 1. Mint the report number (same convention as the Level 1 report)
+					reportNumber := s.generateReportNumber(tx, session, now)
+
+					// 2. Aggregate Data from Attempts
+					snapshot := collectReportScores(tx, session.ID)
+
+					// Create Report Record
+					newReport := models.AssessmentReports{
+						AssessmentSessionID: session.ID,
+						ReportNumber:        reportNumber,
+						GeneratedAt:         now,
+						DiscScores:          snapshot.Disc,
+						AgileScores:         snapshot.Agile,
+						Level3Scores:        snapshot.Level3,
+						Level4Scores:        snapshot.Level4,
+						OverallSincerity:    snapshot.OverallSincerity,
+						DominantTraitID:     snapshot.DominantTraitID,
+						Metadata:            snapshot.Metadata,
+					}
+
+					// Save
+					if err := tx.Create(&newReport).Error; err != nil {
+						fmt.Printf("ERROR: Failed to create Assessment Report: %v\n", err)
+					} else {
+						fmt.Printf("SUCCESS: Assessment Report Created. ID: %d\n", newReport.ID)
+					}
+				} else {
+					// Report already exists - it was created when Level 1
+					// completed (so the Level 1 report could be downloaded
+					// early). Backfill the full score snapshot now that every
+					// level is done, keeping the original report number.
+					snapshot := collectReportScores(tx, session.ID)
+
+					if err := tx.Model(&existingReport).Updates(map[string]interface{}{
+						"disc_scores":       snapshot.Disc,
+						"agile_scores":      snapshot.Agile,
+						"level3_scores":     snapshot.Level3,
+						"level4_scores":     snapshot.Level4,
+						"overall_sincerity": snapshot.OverallSincerity,
+						"dominant_trait_id": snapshot.DominantTraitID,
+						"metadata":          gorm.Expr("COALESCE(metadata, '{}'::jsonb) || ?::jsonb", snapshot.Metadata),
+					}).Error; err != nil {
+						fmt.Printf("ERROR: Failed to backfill Assessment Report %d: %v\n", existingReport.ID, err)
+					} else {
+						fmt.Printf("SUCCESS: Assessment Report %d backfilled (%s).\n", existingReport.ID, existingReport.ReportNumber)
+					}
+				}
+
+				// Update Group Assessment Status
+				if session.GroupID != nil {
+					var groupAssessment models.GroupAssessment
+					tx.Where("group_id = ? AND program_id = ?", *session.GroupID, session.ProgramID).First(&groupAssessment)
+
+					var stats struct {
+						Total     int64
+						Started   int64
+						Completed int64
+					}
+
+					// Count sessions in the group for this program
+					tx.Model(&models.AssessmentSession{}).
+						Where("group_id = ? AND program_id = ?", *session.GroupID, session.ProgramID).
+						Select("COUNT(*) as total, COUNT(*) FILTER (WHERE status != 'NOT_STARTED') as started, COUNT(*) FILTER (WHERE status = 'COMPLETED') as completed").
+						Scan(&stats)
+
+					newStatus := "NOT_STARTED"
+					isExpired := groupAssessment.ValidTo != nil && groupAssessment.ValidTo.Before(now)
+
+					if stats.Total > 0 {
+						if stats.Completed == stats.Total {
+							newStatus = "COMPLETED"
+						} else if isExpired {
+							if stats.Started > 0 {
+								// Some completed or started but unfinished AND time expired
+								newStatus = "PARTIALLY_EXPIRED"
+							} else {
+								// No one started AND time expired
+								newStatus = "EXPIRED"
+							}
+						} else {
+							// Not Expired
+							if stats.Started > 0 {
+								newStatus = "IN_PROGRESS"
+							}
+						}
+					}
+
+					// Update the GroupAssessment status
+					tx.Model(&models.GroupAssessment{}).
+						Where("group_id = ? AND program_id = ?", *session.GroupID, session.ProgramID).
+						Update("status", newStatus)
+				}
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		fmt.Printf("[CompleteAttempt] ERROR: Transaction Failed: %v\n", err)
+		return nil, err
+	}
+
+	if result.UserID > 0 && !result.AlreadyCompleted {
+		go notifyStudentService(result.UserID, result.SessionCompleted, result.NextLevelNumber)
+	}
+
+	return result, nil
+}
+
+// attemptLevelID returns the attempt's assessment level, falling back to the
+// level recorded on its answer rows for legacy attempts without one.
+func attemptLevelID(tx *gorm.DB, attempt models.AssessmentAttempt) (int64, error) {
+	if attempt.AssessmentLevelID != nil {
+		return int64(*attempt.AssessmentLevelID), nil
+	}
+	var levelIDs []int64
+	if err := tx.Model(&models.AssessmentAnswer{}).
+		Where("assessment_attempt_id = ?", attempt.ID).
+		Limit(1).
+		Pluck("assessment_level_id", &levelIDs).Error; err != nil {
+		return 0, err
+	}
+	if len(levelIDs) == 0 {
+		return 0, fmt.Errorf("attempt %d has no assessment level", attempt.ID)
+	}
+	return levelIDs[0], nil
+}
+
+// notifyStudentService tells the student service that a session completed
+// (report e-mail) or that the next level unlocked.
+func notifyStudentService(userID int64, isSessCompleted bool, nextLevelNum int) {
+	studentServiceURL := os.Getenv("STUDENT_SERVICE_URL")
+	if studentServiceURL == "" {
+		studentServiceURL = "http://localhost:4004"
+	}
+
+	if isSessCompleted {
+		endpoint := fmt.Sprintf("%s/student/assessment-complete", studentServiceURL)
+		payload := map[string]interface{}{"userId": userID}
+		jsonPayload, _ := json.Marshal(payload)
+
+		fmt.Printf("[CompleteAttempt] Triggering student service for user %d at %s\n", userID, endpoint)
+		resp, err := http.Post(endpoint, "application/json", bytes.NewBuffer(jsonPayload))
+		if err != nil {
+			fmt.Printf("[CompleteAttempt] ERROR HTTP Post to student service: %v\n", err)
+		} else {
+			fmt.Printf("[CompleteAttempt] Triggered student service, status: %s\n", resp.Status)
+			resp.Body.Close()
+		}
+	} else if nextLevelNum > 0 {
+		endpoint := fmt.Sprintf("%s/student/assessment-level-unlocked", studentServiceURL)
+		payload := map[string]interface{}{
+			"userId":      userID,
+			"levelNumber": nextLevelNum,
+		}
+		jsonPayload, _ := json.Marshal(payload)
+
+		fmt.Printf("[CompleteAttempt] Triggering level unlock notification for user %d at %s\n", userID, endpoint)
+		resp, err := http.Post(endpoint, "application/json", bytes.NewBuffer(jsonPayload))
+		if err != nil {
+			fmt.Printf("[CompleteAttempt] ERROR HTTP Post to student service: %v\n", err)
+		} else {
+			fmt.Printf("[CompleteAttempt] Triggered level unlock notification, status: %s\n", resp.Status)
+			resp.Body.Close()
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"exam-engine/internal/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// seedAttempt creates an attempt of user 7 with one answer row per status.
+func seedAttempt(t *testing.T, db *gorm.DB, attempt models.AssessmentAttempt, answerStatuses ...string) models.AssessmentAttempt {
+	t.Helper()
+	attempt.UserID = 7
+	mustCreate(t, db, &attempt)
+	for i, status := range answerStatuses {
+		questionID := int64(100 + i)
+		mustCreate(t, db, &models.AssessmentAnswer{
+			AssessmentAttemptID: attempt.ID,
+			QuestionSource:      "MAIN",
+			MainQuestionID:      &questionID,
+			QuestionSequence:    i + 1,
+			Status:              status,
+		})
+	}
+	return attempt
+}
+
+func wantExamError(t *testing.T, err error, code string) {
+	t.Helper()
+	var examErr *ExamError
+	if !errors.As(err, &examErr) || examErr.Code != code {
+		t.Fatalf("err = %v, want %s", err, code)
+	}
+}
+
+func TestFinishAttemptPreconditions(t *testing.T) {
+	db := newTestDB(t)
+	s := NewExamService()
+	caller := Caller{UserID: 7}
+	past := time.Now().Add(-time.Hour)
+
+	notStarted := seedAttempt(t, db, models.AssessmentAttempt{Status: "NOT_STARTED"}, "ANSWERED")
+	_, err := s.FinishAttempt(notStarted.ID, caller)
+	wantExamError(t, err, ErrCodeNotInProgress)
+
+	nothing := seedAttempt(t, db, models.AssessmentAttempt{Status: "IN_PROGRESS"}, "NOT_ANSWERED", "NOT_ANSWERED")
+	_, err = s.FinishAttempt(nothing.ID, caller)
+	wantExamError(t, err, ErrCodeNothingAnswered)
+
+	expired := seedAttempt(t, db, models.AssessmentAttempt{Status: "IN_PROGRESS", ExpiresAt: &past}, "ANSWERED")
+	_, err = s.FinishAttempt(expired.ID, caller)
+	wantExamError(t, err, ErrCodeExpired)
+
+	_, err = s.FinishAttempt(nothing.ID, Caller{UserID: 8})
+	wantExamError(t, err, ErrCodeAttemptNotFound)
+}
+
+func TestFinishAttemptDeviceLock(t *testing.T) {
+	db := newTestDB(t)
+	db.Exec(`INSERT INTO originbi_settings (category, setting_key, value_type, value_boolean) VALUES ('assessment', 'device_lock_enabled', 'boolean', true)`)
+	lock := `{"device_lock": {"token_hash": "` + hashSecret("token-a") + `", "fingerprint_hash": "` + hashSecret("device-a") + `", "ip": "10.0.0.1"}}`
+	attempt := seedAttempt(t, db, models.AssessmentAttempt{Status: "IN_PROGRESS", Metadata: lock}, "ANSWERED")
+
+	_, err := NewExamService().FinishAttempt(attempt.ID, Caller{UserID: 7, Fingerprint: "device-b", IP: "10.0.0.1", SessionToken: "token-b"})
+	wantExamError(t, err, ErrCodeSessionInvalid)
+}
+
+func TestFinishAttemptReplay(t *testing.T) {
+	db := newTestDB(t)
+	s := NewExamService()
+	completedAt := time.Now().Add(-time.Minute)
+
+	// The coverage recorded at completion wins over the current rows.
+	recorded := seedAttempt(t, db, models.AssessmentAttempt{
+		Status:      "COMPLETED",
+		CompletedAt: &completedAt,
+		Metadata:    `{"completion_mode": "MANUAL_FINISH", "total_questions": 4, "answered_count": 3, "unanswered_count": 1}`,
+	}, "ANSWERED", "ANSWERED", "ANSWERED", "ANSWERED")
+	got, err := s.FinishAttempt(recorded.ID, Caller{UserID: 7})
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if !got.AlreadyCompleted || got.TotalQuestions != 4 || got.AnsweredCount != 3 || got.UnansweredCount != 1 {
+		t.Errorf("replay = %+v, want the recorded 3 of 4", got)
+	}
+
+	// Attempts completed before coverage was recorded count their rows.
+	legacy := seedAttempt(t, db, models.AssessmentAttempt{Status: "COMPLETED", CompletedAt: &completedAt}, "ANSWERED", "NOT_ANSWERED")
+	got, err = s.FinishAttempt(legacy.ID, Caller{UserID: 7})
+	if err != nil {
+		t.Fatalf("legacy replay: %v", err)
+	}
+	if !got.AlreadyCompleted || got.TotalQuestions != 2 || got.AnsweredCount != 1 || got.UnansweredCount != 1 {
+		t.Errorf("legacy replay = %+v, want 1 of 2", got)
+	}
+}
+
+func TestCompleteAttemptRequiresInProgress(t *testing.T) {
+	db := newTestDB(t)
+	s := NewExamService()
+
+	// E.g. the scheduler expired it after the caller's unlocked read.
+	for _, status := range []string{"PARTIALLY_EXPIRED", "EXPIRED", "NOT_STARTED"} {
+		attempt := seedAttempt(t, db, models.AssessmentAttempt{Status: status}, "ANSWERED")
+		for _, mode := range []string{completionAllAnswered, completionManual, completionAutoSubmit} {
+			_, err := s.completeAttempt(db, attempt.ID, mode)
+			wantExamError(t, err, ErrCodeNotInProgress)
+		}
+		var reloaded models.AssessmentAttempt
+		db.First(&reloaded, attempt.ID)
+		if reloaded.Status != status {
+			t.Errorf("status = %s, want %s left alone", reloaded.Status, status)
+		}
+	}
+}
+
+func TestRecordCoverage(t *testing.T) {
+	meta := map[string]interface{}{"completion_mode": "ALL_ANSWERED", "other": true}
+	recordCoverage(meta, completionManual, &completionResult{TotalQuestions: 10, AnsweredCount: 7})
+
+	want := map[string]interface{}{
+		"completion_mode":  completionManual,
+		"total_questions":  int64(10),
+		"answered_count":   int64(7),
+		"unanswered_count": int64(3),
+		"other":            true,
+	}
+	for k, v := range want {
+		if meta[k] != v {
+			t.Errorf("%s = %v, want %v", k, meta[k], v)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"exam-engine/internal/models"
+	"exam-engine/internal/repository"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxDeviceSwitches bounds the switch log kept in the attempt metadata.
+const maxDeviceSwitches = 20
+
+// Caller is who acts on an attempt: the user (0 when authentication is off
+// and the operation does not name one) and the device session sent with the
+// request.
+type Caller struct {
+	UserID       int64
+	Fingerprint  string
+	IP           string
+	SessionToken string
+}
+
+// deviceLock binds an attempt to one device. Only hashes of the session
+// token and the fingerprint are stored (metadata "device_lock").
+type deviceLock struct {
+	TokenHash       string    `json:"token_hash"`
+	FingerprintHash string    `json:"fingerprint_hash"`
+	IP              string    `json:"ip"`
+	IssuedAt        time.Time `json:"issued_at"`
+}
+
+// deviceSwitch is one entry of the metadata "device_switches" log.
+type deviceSwitch struct {
+	At              time.Time `json:"at"`
+	Reason          string    `json:"reason"` // TAKEOVER or IP_CHANGE
+	FromFingerprint string    `json:"from_fingerprint"`
+	ToFingerprint   string    `json:"to_fingerprint"`
+	FromIP          string    `json:"from_ip"`
+	ToIP            string    `json:"to_ip"`
+}
+
+func hashSecret(v string) string {
+	sum := sha256.Sum256([]byte(v))
+	return hex.EncodeToString(sum[:])
+}
+
+// shortHash is enough of a fingerprint hash to tell devices apart in logs.
+func shortHash(h string) string {
+	if len(h) > 12 {
+		return h[:12]
+	}
+	return h
+}
+
+func newSessionToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func deviceLockEnabled(db *gorm.DB) bool {
+	return settingBool(db, "assessment", "device_lock_enabled", false)
+}
+
+// enforceDeviceLock checks the caller's device session when the lock is
+// enabled. A changed IP is only refused with device_lock_strict_ip on:
+// mobile and school networks change addresses under a candidate.
+func enforceDeviceLock(db *gorm.DB, attempt models.AssessmentAttempt, caller Caller) error {
+	if !deviceLockEnabled(db) {
+		return nil
+	}
+	return checkDeviceSession(attempt, caller, settingBool(db, "assessment", "device_lock_strict_ip", false))
+}
+
+// readDeviceLock extracts the lock and switch log from attempt metadata.
+func readDeviceLock(rawMetadata string) (*deviceLock, []deviceSwitch) {
+	var meta struct {
+		Lock     *deviceLock    `json:"device_lock"`
+		Switches []deviceSwitch `json:"device_switches"`
+	}
+	if rawMetadata != "" {
+		_ = json.Unmarshal([]byte(rawMetadata), &meta)
+	}
+	return meta.Lock, meta.Switches
+}
+
+// checkDeviceSession verifies the session presented with a request against
+// the attempt's lock. Without strictIP an IP change is logged, not refused.
+func checkDeviceSession(attempt models.AssessmentAttempt, caller Caller, strictIP bool) error {
+	invalid := func(reason, message string) error {
+		return &ExamError{
+			Code:    ErrCodeSessionInvalid,
+			Message: message,
+			Details: map[string]interface{}{"reason": reason},
+		}
+	}
+
+	lock, _ := readDeviceLock(attempt.Metadata)
+	switch {
+	case lock == nil:
+		return invalid("NO_SESSION", "start or resume the exam to open a device session")
+	case caller.SessionToken == "":
+		return invalid("MISSING_TOKEN", "attempt session token is required")
+	case subtle.ConstantTimeCompare([]byte(hashSecret(caller.SessionToken)), []byte(lock.TokenHash)) != 1:
+		return invalid("REVOKED", "this device session is no longer valid; the exam was opened on another device")
+	case hashSecret(caller.Fingerprint) != lock.FingerprintHash:
+		return invalid("DEVICE_MISMATCH", "session token was issued to a different device")
+	case caller.IP != lock.IP && strictIP:
+		return invalid("IP_CHANGED", "network changed; resume the exam to continue")
+	case caller.IP != lock.IP:
+		fmt.Printf("[DeviceLock] Attempt %d: IP %s -> %s on the locked device, allowed\n", attempt.ID, lock.IP, caller.IP)
+	}
+	return nil
+}
+
+// bindDevice issues a new session token for the caller's device. A start or
+// resume from the locked device (or a fresh attempt) just rotates the token;
+// another device gets ErrCodeDeviceLocked unless takeover is set, in which
+// case the old device is invalidated and the switch logged.
+func bindDevice(tx *gorm.DB, attemptID int64, caller Caller, takeover bool) (string, error) {
+	if caller.Fingerprint == "" {
+		return "", &ExamError{
+			Code:    ErrCodeSessionInvalid,
+			Message: "device_fingerprint is required",
+			Details: map[string]interface{}{"reason": "FINGERPRINT_REQUIRED"},
+		}
+	}
+
+	var attempt models.AssessmentAttempt
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&attempt, attemptID).Error; err != nil {
+		return "", &ExamError{Code: ErrCodeAttemptNotFound, Message: "assessment attempt not found"}
+	}
+
+	now := time.Now()
+	fingerprint := hashSecret(caller.Fingerprint)
+	lock, switches := readDeviceLock(attempt.Metadata)
+
+	if lock != nil && lock.FingerprintHash != fingerprint && !takeover {
+		return "", &ExamError{
+			Code:    ErrCodeDeviceLocked,
+			Message: "this exam is open on another device",
+			Details: map[string]interface{}{"locked_since": lock.IssuedAt, "takeover_allowed": true},
+		}
+	}
+
+	var reason string
+	switch {
+	case lock == nil:
+	case lock.FingerprintHash != fingerprint:
+		reason = "TAKEOVER"
+	case lock.IP != caller.IP:
+		reason = "IP_CHANGE"
+	}
+	if reason != "" {
+		switches = append(switches, deviceSwitch{
+			At:              now,
+			Reason:          reason,
+			FromFingerprint: shortHash(lock.FingerprintHash),
+			ToFingerprint:   shortHash(fingerprint),
+			FromIP:          lock.IP,
+			ToIP:            caller.IP,
+		})
+		if len(switches) > maxDeviceSwitches {
+			switches = switches[len(switches)-maxDeviceSwitches:]
+		}
+		fmt.Printf("[DeviceLock] Attempt %d: %s %s -> %s\n", attemptID, reason, lock.IP, caller.IP)
+	}
+
+	token, err := newSessionToken()
+	if err != nil {
+		return "", err
+	}
+	fields := map[string]interface{}{
+		"device_lock": deviceLock{
+			TokenHash:       hashSecret(token),
+			FingerprintHash: fingerprint,
+			IP:              caller.IP,
+			IssuedAt:        now,
+		},
+	}
+	if len(switches) > 0 {
+		fields["device_switches"] = switches
+	}
+	if err := mergeAttemptMetadata(tx, attemptID, fields); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// TakeOverAttempt moves an attempt's device session to the caller's device.
+// The previous device's token stops working immediately.
+func (s *ExamService) TakeOverAttempt(attemptID int64, caller Caller) (string, error) {
+	db := repository.GetDB()
+	if !deviceLockEnabled(db) {
+		return "", &ExamError{Code: ErrCodeSessionInvalid, Message: "device lock is not enabled", Details: map[string]interface{}{"reason": "DISABLED"}}
+	}
+
+	var attempt models.AssessmentAttempt
+	if err := db.Where("id = ? AND user_id = ?", attemptID, caller.UserID).First(&attempt).Error; err != nil {
+		return "", &ExamError{Code: ErrCodeAttemptNotFound, Message: "assessment attempt not found or access denied"}
+	}
+	if err := checkAttemptAccess(attempt, time.Now()); err != nil {
+		return "", err
+	}
+
+	var token string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		token, err = bindDevice(tx, attemptID, caller, true)
+		return err
+	})
+	return token, err
+}
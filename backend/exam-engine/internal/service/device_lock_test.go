@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"exam-engine/internal/models"
+	"testing"
+	"time"
+)
+
+func lockedAttempt(t *testing.T, token, fingerprint, ip string) models.AssessmentAttempt {
+	t.Helper()
+	meta, err := json.Marshal(map[string]interface{}{
+		"device_lock": deviceLock{
+			TokenHash:       hashSecret(token),
+			FingerprintHash: hashSecret(fingerprint),
+			IP:              ip,
+			IssuedAt:        time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.AssessmentAttempt{ID: 1, Status: "IN_PROGRESS", Metadata: string(meta)}
+}
+
+func TestCheckDeviceSession(t *testing.T) {
+	attempt := lockedAttempt(t, "tok-1", "fp-laptop", "10.0.0.1")
+
+	cases := []struct {
+		name     string
+		attempt  models.AssessmentAttempt
+		caller   Caller
+		strictIP bool
+		reason   string
+	}{
+		{"matching session", attempt, Caller{SessionToken: "tok-1", Fingerprint: "fp-laptop", IP: "10.0.0.1"}, true, ""},
+		{"never started", models.AssessmentAttempt{Metadata: "{}"}, Caller{SessionToken: "tok-1"}, false, "NO_SESSION"},
+		{"missing token", attempt, Caller{Fingerprint: "fp-laptop", IP: "10.0.0.1"}, false, "MISSING_TOKEN"},
+		{"revoked token", attempt, Caller{SessionToken: "tok-0", Fingerprint: "fp-laptop", IP: "10.0.0.1"}, false, "REVOKED"},
+		{"other device", attempt, Caller{SessionToken: "tok-1", Fingerprint: "fp-phone", IP: "10.0.0.1"}, false, "DEVICE_MISMATCH"},
+		{"other network, strict", attempt, Caller{SessionToken: "tok-1", Fingerprint: "fp-laptop", IP: "10.0.0.2"}, true, "IP_CHANGED"},
+		{"other network", attempt, Caller{SessionToken: "tok-1", Fingerprint: "fp-laptop", IP: "10.0.0.2"}, false, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := checkDeviceSession(c.attempt, c.caller, c.strictIP)
+			if c.reason == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var examErr *ExamError
+			if !errors.As(err, &examErr) || examErr.Code != ErrCodeSessionInvalid {
+				t.Fatalf("err = %v, want %s", err, ErrCodeSessionInvalid)
+			}
+			if got := examErr.Details["reason"]; got != c.reason {
+				t.Errorf("reason = %v, want %s", got, c.reason)
+			}
+		})
+	}
+}
+
+func TestReadDeviceLockKeepsSwitchLog(t *testing.T) {
+	raw := `{"device_lock":{"ip":"10.0.0.2"},"device_switches":[{"reason":"TAKEOVER","from_ip":"10.0.0.1","to_ip":"10.0.0.2"}]}`
+	lock, switches := readDeviceLock(raw)
+	if lock == nil || lock.IP != "10.0.0.2" {
+		t.Fatalf("lock = %+v", lock)
+	}
+	if len(switches) != 1 || switches[0].Reason != "TAKEOVER" {
+		t.Fatalf("switches = %+v", switches)
+	}
+	if lock, _ := readDeviceLock(""); lock != nil {
+		t.Errorf("empty metadata gave lock %+v", lock)
+	}
+}
+
+func TestDeviceLockAppliesToEvents(t *testing.T) {
+	db := newTestDB(t)
+	db.Exec(`INSERT INTO originbi_settings (category, setting_key, value_type, value_boolean) VALUES ('assessment', 'device_lock_enabled', 'boolean', true)`)
+	locked := lockedAttempt(t, "tok-1", "fp-laptop", "10.0.0.1")
+	attempt := seedAttempt(t, db, models.AssessmentAttempt{Status: "IN_PROGRESS", Metadata: locked.Metadata})
+
+	batch := models.ProctoringEventBatch{Events: []models.ProctoringEventInput{{Type: "TAB_SWITCH"}}}
+	_, err := NewExamService().RecordProctoringEvents(attempt.ID, Caller{UserID: 7, SessionToken: "tok-0", Fingerprint: "fp-laptop", IP: "10.0.0.1"}, batch)
+	wantExamError(t, err, ErrCodeSessionInvalid)
+}
+
+func TestTakeOverAttemptNeedsTheOwner(t *testing.T) {
+	db := newTestDB(t)
+	db.Exec(`INSERT INTO originbi_settings (category, setting_key, value_type, value_boolean) VALUES ('assessment', 'device_lock_enabled', 'boolean', true)`)
+	attempt := seedAttempt(t, db, models.AssessmentAttempt{Status: "IN_PROGRESS"})
+
+	// Without auth the handler requires student_id; 0 is nobody's attempt.
+	for _, userID := range []int64{0, 8} {
+		_, err := NewExamService().TakeOverAttempt(attempt.ID, Caller{UserID: userID, Fingerprint: "fp-phone"})
+		wantExamError(t, err, ErrCodeAttemptNotFound)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import "testing"
+
+func TestDiscTraitPolicyResolve(t *testing.T) {
+	// D18 of total 40: rule (1) fails (45%), rule (2) holds (18 > 2*8).
+	dominant := map[string]float64{"D": 18, "I": 8, "S": 8, "C": 6}
+	// D20 of total 40: rule (1) holds at exactly 50%, rule (2) fails (20 = 2*10).
+	half := map[string]float64{"D": 20, "I": 10, "S": 5, "C": 5}
+
+	strict := defaultDiscTraitPolicy
+	strict.RequireAll = true
+	higherShare := defaultDiscTraitPolicy
+	higherShare.ShareOfTotal = 0.6
+	higherShare.DominanceRatio = 0
+	isFirst := defaultDiscTraitPolicy
+	isFirst.Priority = "isdc"
+
+	cases := []struct {
+		name     string
+		policy   discTraitPolicy
+		scores   map[string]float64
+		wantCode string
+		wantRule string
+	}{
+		{"default, dominance", defaultDiscTraitPolicy, dominant, "D", discRuleDominance},
+		{"default, share", defaultDiscTraitPolicy, half, "D", discRuleShareOfTotal},
+		{"default, blend", defaultDiscTraitPolicy, map[string]float64{"D": 22, "I": 16, "S": 6, "C": 8}, "DI", discRuleBlend},
+		{"single factor", defaultDiscTraitPolicy, map[string]float64{"C": 3}, "C", discRuleSingleFactor},
+		{"require all, only dominance holds", strict, dominant, "DI", discRuleBlend},
+		{"require all, both hold", strict, map[string]float64{"D": 30, "I": 5, "S": 5, "C": 5}, "D", discRuleAllRules},
+		{"higher share, dominance off", higherShare, half, "DI", discRuleBlend},
+		{"custom tie-break", isFirst, map[string]float64{"D": 10, "I": 10, "S": 10, "C": 10}, "IS", discRuleBlend},
+		{"invalid priority falls back", discTraitPolicy{ShareOfTotal: 0.5, Priority: "DDIS"}, map[string]float64{"D": 10, "I": 10, "S": 10, "C": 10}, "CD", discRuleBlend},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			code, rule := c.policy.resolve(c.scores)
+			if code != c.wantCode || rule != c.wantRule {
+				t.Errorf("got (%s, %s), want (%s, %s)", code, rule, c.wantCode, c.wantRule)
+			}
+		})
+	}
+}
+
+func TestBuildDiscTraitPolicy(t *testing.T) {
+	version := "disc-v2"
+	priority := "DISC"
+	requireAll := true
+	override := `{"version": "cxo-strict", "pure_share_of_total": 0.6}`
+	rows := []settingsRow{
+		{SettingKey: "policy_version", ValueType: "string", ValueString: &version},
+		sincerityNumberRow("pure_dominance_ratio", 2.5),
+		{SettingKey: "priority", ValueType: "string", ValueString: &priority},
+		{SettingKey: "pure_require_all", ValueType: "boolean", ValueBoolean: &requireAll},
+		{SettingKey: "program_4", ValueType: "json", ValueJSON: &override},
+	}
+
+	base := buildDiscTraitPolicy(rows, 1)
+	want := discTraitPolicy{Version: "disc-v2", ShareOfTotal: 0.5, DominanceRatio: 2.5, RequireAll: true, Priority: "DISC"}
+	if base != want {
+		t.Errorf("base = %+v, want %+v", base, want)
+	}
+
+	cxo := buildDiscTraitPolicy(rows, 4)
+	want.Version, want.ShareOfTotal = "cxo-strict", 0.6
+	if cxo != want {
+		t.Errorf("program 4 = %+v, want %+v", cxo, want)
+	}
+
+	if got := buildDiscTraitPolicy(nil, 4); got != defaultDiscTraitPolicy {
+		t.Errorf("no settings = %+v, want the default", got)
+	}
+}
@@ -0,0 +1,87 @@
+package service
+
+// Intensity bands of a DISC factor.
+const (
+	discBandLow  = "LOW"
+	discBandMid  = "MID"
+	discBandHigh = "HIGH"
+)
+
+// discSegmentBounds split a factor's share of D+I+S+C (in %) into the seven
+// segments of the classic DISC graph: below 5 is segment 1, from 45 is
+// segment 7. Segment 4 is the midline around an even 25% share; segments
+// 1-3 are low intensity and 5-7 high.
+var discSegmentBounds = [...]float64{5, 12.5, 20, 30, 37.5, 45}
+
+// discFactorProfile is one factor as the report graphs show it.
+type discFactorProfile struct {
+	Score   float64 `json:"score"`
+	Percent float64 `json:"percent"`
+	Band    string  `json:"band"`
+	Segment int     `json:"segment"`
+}
+
+// discProfile is the graph data derived from Level 1 factor sums, stored
+// with the scores so every report renders the same numbers.
+type discProfile struct {
+	Factors map[string]discFactorProfile `json:"factors"`
+	// Code is the dominant trait code and Label its
+	// personality_traits.blended_style_name, empty when the trait is
+	// unknown.
+	Code  string `json:"code"`
+	Label string `json:"label,omitempty"`
+	Pure  bool   `json:"pure"`
+}
+
+// buildDiscProfile returns the profile of factor sums, or nil when nothing
+// positive was scored. Every factor is listed; missing ones count as 0.
+func buildDiscProfile(scores map[string]float64, code, label string) *discProfile {
+	var total float64
+	for _, f := range []string{"D", "I", "S", "C"} {
+		total += scores[f]
+	}
+	if total <= 0 {
+		return nil
+	}
+
+	profile := &discProfile{
+		Factors: make(map[string]discFactorProfile, 4),
+		Code:    code,
+		Label:   label,
+		Pure:    len(code) == 1,
+	}
+	for _, f := range []string{"D", "I", "S", "C"} {
+		percent := roundHundredths(scores[f] / total * 100)
+		segment := discSegment(percent)
+		profile.Factors[f] = discFactorProfile{
+			Score:   scores[f],
+			Percent: percent,
+			Band:    discBand(segment),
+			Segment: segment,
+		}
+	}
+	return profile
+}
+
+// discSegment places a percentage on the 1-7 graph scale.
+func discSegment(percent float64) int {
+	segment := 1
+	for _, bound := range discSegmentBounds {
+		if percent >= bound {
+			segment++
+		}
+	}
+	return segment
+}
+
+// discBand is the intensity band of a graph segment.
+func discBand(segment int) string {
+	switch {
+	case segment < 4:
+		return discBandLow
+	case segment > 4:
+		return discBandHigh
+	default:
+		return discBandMid
+	}
+}
@@ -0,0 +1,34 @@
+package service
+
+import "testing"
+
+func TestBuildDiscProfile(t *testing.T) {
+	p := buildDiscProfile(map[string]float64{"D": 22, "I": 16, "S": 6}, "DI", "Driver-Influencer")
+	if p == nil {
+		t.Fatal("profile = nil")
+	}
+	want := map[string]discFactorProfile{
+		"D": {Score: 22, Percent: 50, Band: discBandHigh, Segment: 7},
+		"I": {Score: 16, Percent: 36.36, Band: discBandHigh, Segment: 5},
+		"S": {Score: 6, Percent: 13.64, Band: discBandLow, Segment: 3},
+		"C": {Score: 0, Percent: 0, Band: discBandLow, Segment: 1},
+	}
+	for f, w := range want {
+		if got := p.Factors[f]; got != w {
+			t.Errorf("%s = %+v, want %+v", f, got, w)
+		}
+	}
+	if p.Code != "DI" || p.Label != "Driver-Influencer" || p.Pure {
+		t.Errorf("code/label/pure = %s/%s/%v", p.Code, p.Label, p.Pure)
+	}
+
+	if p := buildDiscProfile(map[string]float64{"D": 10, "I": 10, "S": 10, "C": 10}, "CD", ""); p.Factors["D"].Segment != 4 || p.Factors["D"].Band != discBandMid {
+		t.Errorf("even share = %+v, want the midline", p.Factors["D"])
+	}
+	if p := buildDiscProfile(map[string]float64{"C": 3}, "C", ""); !p.Pure {
+		t.Error("single-letter code should be pure")
+	}
+	if p := buildDiscProfile(map[string]float64{}, "", ""); p != nil {
+		t.Errorf("nothing scored = %+v, want nil", p)
+	}
+}
@@ -1,6 +1,46 @@
 package service
 
-import "sort"
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Pure-trait rules recorded on the attempt, explaining the dominant code.
+const (
+	discRuleSingleFactor = "SINGLE_FACTOR"  // only one factor scored
+	discRuleShareOfTotal = "SHARE_OF_TOTAL" // rule (1)
+	discRuleDominance    = "DOMINANCE"      // rule (2)
+	discRuleAllRules     = "ALL_RULES"      // every enabled rule, when all are required
+	discRuleBlend        = "BLEND"          // top-two blend
+)
+
+// discTraitPolicy is the pure-trait rule set. It is read from
+// originbi_settings, category "disc": number/string rows named after the
+// json tags, "policy_version", and json rows "program_<id>" overriding any
+// subset of fields for one program.
+type discTraitPolicy struct {
+	Version string `json:"version"`
+	// ShareOfTotal is rule (1): top >= ShareOfTotal * total. 0 disables it.
+	ShareOfTotal float64 `json:"pure_share_of_total"`
+	// DominanceRatio is rule (2): top > DominanceRatio * every other
+	// factor. 0 disables it.
+	DominanceRatio float64 `json:"pure_dominance_ratio"`
+	// RequireAll makes a trait pure only when every enabled rule holds;
+	// by default one is enough.
+	RequireAll bool `json:"pure_require_all"`
+	// Priority is the tie-break order, highest first.
+	Priority string `json:"priority"`
+}
+
+// defaultDiscTraitPolicy is the rule set the engine has always used.
+var defaultDiscTraitPolicy = discTraitPolicy{
+	Version:        "default",
+	ShareOfTotal:   0.5,
+	DominanceRatio: 2,
+	Priority:       "CDIS",
+}
 
 // discFactorPriority is the deterministic tie-break order C > D > I > S, applied
 // when two factors share the same score. It mirrors getTopTwoTraits in the
@@ -28,7 +68,17 @@ var discFactorPriority = map[string]int{"C": 0, "D": 1, "I": 2, "S": 3}
 // keys (such as "total") are ignored. Missing factors are treated as absent
 // (i.e. score 0 and excluded from the total), matching the SQL aggregation that
 // only returns factors the candidate actually scored on.
+//
+// These are the default rules; programs may configure others (see
+// discTraitPolicy).
 func ResolveDominantFactor(scores map[string]float64) string {
+	code, _ := defaultDiscTraitPolicy.resolve(scores)
+	return code
+}
+
+// resolve applies the policy to factor sums and returns the dominant code
+// with the rule that produced it.
+func (p discTraitPolicy) resolve(scores map[string]float64) (string, string) {
 	type factorScore struct {
 		factor string
 		score  float64
@@ -46,39 +96,107 @@ func ResolveDominantFactor(scores map[string]float64) string {
 	}
 
 	if len(list) == 0 {
-		return ""
+		return "", ""
 	}
 
+	priority := p.priorityRanks()
 	sort.SliceStable(list, func(i, j int) bool {
 		if list[i].score != list[j].score {
 			return list[i].score > list[j].score
 		}
-		return discFactorPriority[list[i].factor] < discFactorPriority[list[j].factor]
+		return priority[list[i].factor] < priority[list[j].factor]
 	})
 
 	top := list[0]
 	if len(list) == 1 {
-		return top.factor
+		return top.factor, discRuleSingleFactor
 	}
 
 	if top.score > 0 && total > 0 {
-		// Rule (1): top is at least half of the whole distribution.
-		if top.score*2 >= total {
-			return top.factor
-		}
-		// Rule (2): top more than doubles every other dimension individually.
-		dominatesAll := true
+		// Rule (1): top is at least the configured share of the whole distribution.
+		shareHolds := p.ShareOfTotal > 0 && top.score >= p.ShareOfTotal*total
+		// Rule (2): top more than DominanceRatio times every other dimension individually.
+		dominanceHolds := p.DominanceRatio > 0
 		for _, r := range list[1:] {
-			if !(top.score/2 > r.score) {
-				dominatesAll = false
+			if !(top.score > p.DominanceRatio*r.score) {
+				dominanceHolds = false
 				break
 			}
 		}
-		if dominatesAll {
-			return top.factor
+
+		if p.RequireAll {
+			enabled := p.ShareOfTotal > 0 || p.DominanceRatio > 0
+			if enabled && (p.ShareOfTotal <= 0 || shareHolds) && (p.DominanceRatio <= 0 || dominanceHolds) {
+				return top.factor, discRuleAllRules
+			}
+		} else if shareHolds {
+			return top.factor, discRuleShareOfTotal
+		} else if dominanceHolds {
+			return top.factor, discRuleDominance
 		}
 	}
 
 	// Standard dual-trait blend (unchanged 12-combination behaviour).
-	return top.factor + list[1].factor
+	return top.factor + list[1].factor, discRuleBlend
+}
+
+// priorityRanks turns Priority into ranks, falling back to C > D > I > S
+// unless it names each of D, I, S and C exactly once.
+func (p discTraitPolicy) priorityRanks() map[string]int {
+	order := strings.ToUpper(strings.TrimSpace(p.Priority))
+	if len(order) != 4 {
+		return discFactorPriority
+	}
+	ranks := make(map[string]int, 4)
+	for i, r := range order {
+		f := string(r)
+		if _, known := discFactorPriority[f]; !known {
+			return discFactorPriority
+		}
+		if _, dup := ranks[f]; dup {
+			return discFactorPriority
+		}
+		ranks[f] = i
+	}
+	return ranks
+}
+
+// buildDiscTraitPolicy returns the policy for a program from the "disc"
+// settings rows. An override without its own "version" is labelled
+// "<base version>+program_<id>".
+func buildDiscTraitPolicy(rows []settingsRow, programID int64) discTraitPolicy {
+	p := defaultDiscTraitPolicy
+	p.Version = policyVersionRow(rows, p.Version)
+	for _, row := range rows {
+		switch {
+		case row.SettingKey == "policy_version":
+		case row.ValueNumber != nil:
+			applySettingField(&p, row.SettingKey, *row.ValueNumber)
+		case row.ValueString != nil:
+			applySettingField(&p, row.SettingKey, *row.ValueString)
+		case row.ValueBoolean != nil:
+			applySettingField(&p, row.SettingKey, *row.ValueBoolean)
+		}
+	}
+
+	raw, ok := programOverrideRows(rows)[programID]
+	if !ok {
+		return p
+	}
+	base := p
+	p.Version = ""
+	if err := json.Unmarshal(raw, &p); err != nil {
+		fmt.Printf("[DISC] Ignoring override for Program %d: %v\n", programID, err)
+		return base
+	}
+	if p.Version == "" {
+		p.Version = fmt.Sprintf("%s+program_%d", base.Version, programID)
+	}
+	return p
+}
+
+// loadDiscTraitPolicy returns the policy for a program from the cached
+// "disc" settings category.
+func loadDiscTraitPolicy(programID int64) discTraitPolicy {
+	return buildDiscTraitPolicy(policySettings.rows("disc"), programID)
 }
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reasons a candidate may not start an attempt (Details["reason"] of an
+// ErrCodeNotEligible error).
+const (
+	ineligiblePaymentPending   = "PAYMENT_PENDING"
+	ineligibleUserBlocked      = "USER_BLOCKED"
+	ineligibleUserInactive     = "USER_INACTIVE"
+	ineligibleCorporateBlocked = "CORPORATE_BLOCKED"
+)
+
+// eligibilityFacts is what the evaluator looks at. Missing rows are nil and
+// are not held against the candidate.
+type eligibilityFacts struct {
+	Registration *models.Registration
+	User         *models.User
+	Corporate    *models.CorporateAccount
+}
+
+// evaluateEligibility returns the first rule the candidate fails, or nil.
+func evaluateEligibility(facts eligibilityFacts) *ExamError {
+	deny := func(reason, message string) *ExamError {
+		return &ExamError{
+			Code:    ErrCodeNotEligible,
+			Message: message,
+			Details: map[string]interface{}{"reason": reason},
+		}
+	}
+
+	if u := facts.User; u != nil {
+		if u.IsBlocked {
+			return deny(ineligibleUserBlocked, "your account is blocked")
+		}
+		if !u.IsActive {
+			return deny(ineligibleUserInactive, "your account is not active")
+		}
+	}
+	if c := facts.Corporate; c != nil && c.IsBlocked {
+		return deny(ineligibleCorporateBlocked, "your organisation's account is blocked")
+	}
+	if r := facts.Registration; r != nil && r.PaymentRequired && !isPaymentSettled(r.PaymentStatus) {
+		err := deny(ineligiblePaymentPending, "payment for this registration is not complete")
+		err.Details["payment_status"] = r.PaymentStatus
+		return err
+	}
+	return nil
+}
+
+func isPaymentSettled(status string) bool {
+	switch strings.ToUpper(strings.TrimSpace(status)) {
+	case "PAID", "SUCCESS", "NOT_REQUIRED", "NA":
+		return true
+	}
+	return false
+}
+
+// checkEligibility loads the registration, user and corporate account behind
+// an attempt and refuses the start when any of them fails the rules. Denials
+// are recorded on the attempt metadata under "eligibility".
+func (s *ExamService) checkEligibility(db *gorm.DB, attempt models.AssessmentAttempt) error {
+	var facts eligibilityFacts
+
+	var user models.User
+	if err := db.First(&user, attempt.UserID).Error; err == nil {
+		facts.User = &user
+	}
+	var reg models.Registration
+	if err := db.First(&reg, attempt.RegistrationID).Error; err == nil {
+		facts.Registration = &reg
+		if reg.CorporateAccountID != nil {
+			var corporate models.CorporateAccount
+			if err := db.First(&corporate, *reg.CorporateAccountID).Error; err == nil {
+				facts.Corporate = &corporate
+			}
+		}
+	}
+
+	denial := evaluateEligibility(facts)
+	if denial == nil {
+		return nil
+	}
+
+	fmt.Printf("[GetExamQuestions] NOT ELIGIBLE: Attempt %d User %d reason=%v\n", attempt.ID, attempt.UserID, denial.Details["reason"])
+	record := map[string]interface{}{
+		"allowed":    false,
+		"reason":     denial.Details["reason"],
+		"checked_at": time.Now(),
+	}
+	if err := mergeAttemptMetadata(db, attempt.ID, map[string]interface{}{"eligibility": record}); err != nil {
+		fmt.Printf("[GetExamQuestions] Failed to record eligibility for Attempt %d: %v\n", attempt.ID, err)
+	}
+	return denial
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"testing"
+)
+
+func TestEvaluateEligibility(t *testing.T) {
+	activeUser := &models.User{IsActive: true}
+	cases := []struct {
+		name  string
+		facts eligibilityFacts
+		want  string
+	}{
+		{"nothing loaded", eligibilityFacts{}, ""},
+		{"paid registration", eligibilityFacts{User: activeUser, Registration: &models.Registration{PaymentRequired: true, PaymentStatus: "PAID"}}, ""},
+		{"payment not required", eligibilityFacts{User: activeUser, Registration: &models.Registration{PaymentStatus: "PENDING"}}, ""},
+		{"unpaid registration", eligibilityFacts{User: activeUser, Registration: &models.Registration{PaymentRequired: true, PaymentStatus: "PENDING"}}, ineligiblePaymentPending},
+		{"failed payment", eligibilityFacts{User: activeUser, Registration: &models.Registration{PaymentRequired: true, PaymentStatus: "FAILED"}}, ineligiblePaymentPending},
+		{"blocked user", eligibilityFacts{User: &models.User{IsActive: true, IsBlocked: true}}, ineligibleUserBlocked},
+		{"inactive user", eligibilityFacts{User: &models.User{}}, ineligibleUserInactive},
+		{"blocked tenant", eligibilityFacts{User: activeUser, Corporate: &models.CorporateAccount{IsBlocked: true}}, ineligibleCorporateBlocked},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := evaluateEligibility(c.facts)
+			if c.want == "" {
+				if err != nil {
+					t.Fatalf("unexpected denial: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Code != ErrCodeNotEligible || err.Details["reason"] != c.want {
+				t.Fatalf("got %+v, want reason %s", err, c.want)
+			}
+		})
+	}
+}
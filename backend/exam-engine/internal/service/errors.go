@@ -0,0 +1,54 @@
+package service
+
+// Error codes returned to the exam runner. They are part of the API contract:
+// the frontend switches on them to show countdowns and the right message, so
+// existing values must never change meaning.
+const (
+	// ErrCodeTimeUp means the attempt's must_finish_by deadline has passed.
+	ErrCodeTimeUp = "TIME_UP"
+	// ErrCodeAttemptNotFound means the attempt does not exist or belongs to
+	// someone else; the two are deliberately indistinguishable.
+	ErrCodeAttemptNotFound = "ATTEMPT_NOT_FOUND"
+	// ErrCodeQuestionNotFound means the answered question is not part of the attempt.
+	ErrCodeQuestionNotFound = "QUESTION_NOT_FOUND"
+	// ErrCodeStaleAnswer means a newer write for the same question was
+	// already applied, so this out-of-order retry was discarded.
+	ErrCodeStaleAnswer = "STALE_ANSWER"
+	// ErrCodeNotInProgress means the operation needs an IN_PROGRESS attempt.
+	ErrCodeNotInProgress = "ATTEMPT_NOT_IN_PROGRESS"
+	// ErrCodeNothingAnswered means an attempt cannot be finished with no answers.
+	ErrCodeNothingAnswered = "NOTHING_ANSWERED"
+	// ErrCodeInvalidAnswer means the selection does not fit the question
+	// (no option, too many or too few options, or a foreign option id).
+	ErrCodeInvalidAnswer = "INVALID_ANSWER"
+	// ErrCodeLockedUntil means the attempt's unlock_at is still in the future.
+	ErrCodeLockedUntil = "LOCKED_UNTIL"
+	// ErrCodeExpired means the attempt's expires_at has passed or it was
+	// already marked expired.
+	ErrCodeExpired = "EXPIRED"
+	// ErrCodeAlreadyCompleted means the attempt was completed and cannot be
+	// served or answered again.
+	ErrCodeAlreadyCompleted = "ALREADY_COMPLETED"
+	// ErrCodeNotEligible means the candidate may not start the attempt
+	// (unpaid registration, blocked or inactive user, blocked tenant).
+	ErrCodeNotEligible = "NOT_ELIGIBLE"
+	// ErrCodeDeviceLocked means the attempt is open on another device; the
+	// client may offer a takeover.
+	ErrCodeDeviceLocked = "DEVICE_LOCKED"
+	// ErrCodeSessionInvalid means the request's attempt session token is
+	// missing, revoked or does not match this device.
+	ErrCodeSessionInvalid = "SESSION_INVALID"
+)
+
+// ExamError is a typed, client-facing failure. Handlers translate the Code
+// into an HTTP status and surface Details (timestamps, counts) alongside the
+// message, instead of collapsing everything into a generic 500.
+type ExamError struct {
+	Code    string
+	Message string
+	Details map[string]interface{}
+}
+
+func (e *ExamError) Error() string {
+	return e.Message
+}
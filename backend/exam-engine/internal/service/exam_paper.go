@@ -0,0 +1,196 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// buildExamQuestions converts the preloaded assessment_answers rows of an
+// attempt into the candidate-facing payload. Only whitelisted fields are
+// copied, so nothing that reveals the scoring key (score_value, disc_factor,
+// is_correct, is_valid, metadata) can reach the browser. Stored option orders
+// are applied only when shuffle is set; otherwise options follow display_order.
+func buildExamQuestions(answers []models.AssessmentAnswer, shuffle bool) []models.ExamQuestion {
+	questions := make([]models.ExamQuestion, 0, len(answers))
+	for _, ans := range answers {
+		var order []int
+		if shuffle {
+			order = parseOptionsOrder(ans.QuestionOptionsOrder)
+		}
+
+		item := models.ExamQuestion{
+			ID:                  ans.ID,
+			AssessmentAttemptID: ans.AssessmentAttemptID,
+			QuestionSource:      ans.QuestionSource,
+			QuestionSequence:    ans.QuestionSequence,
+			Status:              ans.Status,
+			MainQuestionID:      ans.MainQuestionID,
+			OpenQuestionID:      ans.OpenQuestionID,
+			MainOptionID:        ans.MainOptionID,
+			OpenOptionID:        ans.OpenOptionID,
+		}
+		if ans.MainQuestion != nil {
+			item.MainQuestion = candidateMainQuestion(ans.MainQuestion, order)
+		}
+		if ans.OpenQuestion != nil {
+			item.OpenQuestion = candidateOpenQuestion(ans.OpenQuestion, order)
+		}
+		questions = append(questions, item)
+	}
+	return questions
+}
+
+func candidateMainQuestion(q *models.AssessmentQuestion, order []int) *models.CandidateQuestion {
+	active := make([]models.AssessmentQuestionOption, 0, len(q.Options))
+	for _, opt := range q.Options {
+		if opt.IsActive && !opt.IsDeleted {
+			active = append(active, opt)
+		}
+	}
+	active = orderOptions(active, func(o models.AssessmentQuestionOption) int { return o.DisplayOrder }, order)
+
+	options := make([]models.CandidateOption, 0, len(active))
+	for _, opt := range active {
+		options = append(options, models.CandidateOption{
+			ID:           opt.ID,
+			OptionTextEn: opt.OptionTextEn,
+			OptionTextTa: opt.OptionTextTa,
+			OptionText:   opt.OptionTextEn,
+		})
+	}
+
+	meta := parseQuestionMeta(q.Metadata)
+	return &models.CandidateQuestion{
+		ID:             q.ID,
+		ContextTextEn:  q.ContextTextEn,
+		ContextTextTa:  q.ContextTextTa,
+		QuestionTextEn: q.QuestionTextEn,
+		QuestionTextTa: q.QuestionTextTa,
+		MultiSelect:    meta.MultiSelect,
+		MinSelections:  meta.MinSelections,
+		MaxSelections:  meta.MaxSelections,
+		ResponseType:   meta.ResponseType,
+		MaxLength:      meta.MaxLength,
+		MinValue:       meta.MinValue,
+		MaxValue:       meta.MaxValue,
+		Options:        options,
+	}
+}
+
+func candidateOpenQuestion(q *models.OpenQuestion, order []int) *models.CandidateQuestion {
+	active := make([]models.OpenQuestionOption, 0, len(q.Options))
+	for _, opt := range q.Options {
+		if opt.IsActive && !opt.IsDeleted {
+			active = append(active, opt)
+		}
+	}
+	active = orderOptions(active, func(o models.OpenQuestionOption) int { return o.DisplayOrder }, order)
+
+	options := make([]models.CandidateOption, 0, len(active))
+	for _, opt := range active {
+		options = append(options, models.CandidateOption{
+			ID:              opt.ID,
+			OptionTextEn:    opt.OptionTextEn,
+			OptionTextTa:    opt.OptionTextTa,
+			OptionText:      opt.OptionTextEn,
+			OptionImageFile: opt.OptionImageFile,
+		})
+	}
+
+	images := make([]models.CandidateQuestionImage, 0, len(q.Images))
+	for _, img := range q.Images {
+		if !img.IsActive || img.IsDeleted {
+			continue
+		}
+		images = append(images, models.CandidateQuestionImage{
+			ID:           img.ID,
+			ImageURL:     img.ImageFile,
+			DisplayOrder: img.DisplayOrder,
+		})
+	}
+	sort.SliceStable(images, func(i, j int) bool { return images[i].DisplayOrder < images[j].DisplayOrder })
+
+	meta := parseQuestionMeta(q.Metadata)
+	return &models.CandidateQuestion{
+		ID:             q.ID,
+		QuestionType:   q.QuestionType,
+		MediaType:      q.MediaType,
+		ContextTextEn:  q.ContextTextEn,
+		ContextTextTa:  q.ContextTextTa,
+		QuestionTextEn: q.QuestionTextEn,
+		QuestionTextTa: q.QuestionTextTa,
+		Question:       q.QuestionTextEn,
+		AudioFile:      q.AudioFile,
+		VideoFile:      q.VideoFile,
+		DocumentFile:   q.DocumentFile,
+		Images:         images,
+		MultiSelect:    meta.MultiSelect,
+		MinSelections:  meta.MinSelections,
+		MaxSelections:  meta.MaxSelections,
+		ResponseType:   meta.ResponseType,
+		MaxLength:      meta.MaxLength,
+		MinValue:       meta.MinValue,
+		MaxValue:       meta.MaxValue,
+		Options:        options,
+	}
+}
+
+// parseOptionsOrder reads assessment_answers.question_options_order, a list
+// of option display_order positions (e.g. "[3,1,4,2]"). The admin service
+// writes it as a JSON array; a plain comma-separated list is also accepted.
+// Anything unparseable yields nil, meaning "use display_order".
+func parseOptionsOrder(raw string) []int {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+
+	var order []int
+	if err := json.Unmarshal([]byte(raw), &order); err == nil {
+		return order
+	}
+
+	order = nil
+	for _, part := range strings.Split(strings.Trim(raw, "[]"), ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return nil
+		}
+		order = append(order, n)
+	}
+	return order
+}
+
+// orderOptions sorts options by display_order and then arranges them in the
+// sequence given by `order` (a list of display_order positions). Positions that
+// match no option are skipped and options missing from `order` keep their
+// display_order sequence at the end, so a stale order can never hide an option.
+func orderOptions[T any](options []T, displayOrder func(T) int, order []int) []T {
+	sort.SliceStable(options, func(i, j int) bool {
+		return displayOrder(options[i]) < displayOrder(options[j])
+	})
+	if len(order) == 0 {
+		return options
+	}
+
+	used := make([]bool, len(options))
+	arranged := make([]T, 0, len(options))
+	for _, pos := range order {
+		for i, opt := range options {
+			if !used[i] && displayOrder(opt) == pos {
+				used[i] = true
+				arranged = append(arranged, opt)
+				break
+			}
+		}
+	}
+	for i, opt := range options {
+		if !used[i] {
+			arranged = append(arranged, opt)
+		}
+	}
+	return arranged
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// scoringKeys are JSON keys that reveal the scoring key and must never appear
+// anywhere in the candidate-facing exam payload.
+var scoringKeys = []string{
+	"score_value", "disc_factor", "is_correct", "is_valid", "metadata",
+	"answer_score", "category", "personality_trait_id", "is_attention_fail",
+	"is_distraction_chosen", "sincerity_flag",
+}
+
+func strPtr(s string) *string { return &s }
+func int64Ptr(v int64) *int64 { return &v }
+
+func sampleAnswers() []models.AssessmentAnswer {
+	disc := "D"
+	return []models.AssessmentAnswer{
+		{
+			ID:                   11,
+			AssessmentAttemptID:  7,
+			QuestionSource:       "MAIN",
+			QuestionSequence:     1,
+			Status:               "NOT_ANSWERED",
+			MainQuestionID:       int64Ptr(101),
+			QuestionOptionsOrder: "[3,1,4,2]",
+			AnswerScore:          4,
+			Metadata:             `{"secret":true}`,
+			MainQuestion: &models.AssessmentQuestion{
+				ID:             101,
+				QuestionTextEn: "Pick one",
+				Category:       "ATTENTION_CHECK",
+				Metadata:       `{"answer":"B"}`,
+				Options: []models.AssessmentQuestionOption{
+					{ID: 1, DisplayOrder: 1, OptionTextEn: "A", DiscFactor: &disc, ScoreValue: 4, IsCorrect: true, IsActive: true, Metadata: `{"k":1}`},
+					{ID: 2, DisplayOrder: 2, OptionTextEn: "B", ScoreValue: 3, IsActive: true},
+					{ID: 3, DisplayOrder: 3, OptionTextEn: "C", ScoreValue: 2, IsActive: true},
+					{ID: 4, DisplayOrder: 4, OptionTextEn: "D", ScoreValue: 1, IsActive: true},
+					{ID: 5, DisplayOrder: 5, OptionTextEn: "deleted", IsActive: true, IsDeleted: true},
+				},
+			},
+		},
+		{
+			ID:               12,
+			QuestionSource:   "OPEN",
+			QuestionSequence: 2,
+			Status:           "ANSWERED",
+			OpenQuestionID:   int64Ptr(201),
+			OpenOptionID:     int64Ptr(22),
+			OpenQuestion: &models.OpenQuestion{
+				ID:             201,
+				QuestionType:   "IMAGE",
+				MediaType:      "IMAGE",
+				QuestionTextEn: "What do you see?",
+				QuestionTextTa: strPtr("?"),
+				Metadata:       `{"answer":22}`,
+				Options: []models.OpenQuestionOption{
+					{ID: 21, DisplayOrder: 1, OptionTextEn: "Cat", IsActive: true},
+					{ID: 22, DisplayOrder: 2, OptionTextEn: "Dog", IsValid: true, IsActive: true},
+				},
+				Images: []models.OpenQuestionImage{
+					{ID: 31, ImageFile: "b.png", DisplayOrder: 2, IsActive: true},
+					{ID: 30, ImageFile: "a.png", DisplayOrder: 1, IsActive: true},
+				},
+			},
+		},
+	}
+}
+
+// collectKeys walks a decoded JSON document and records every object key.
+func collectKeys(v interface{}, keys map[string]bool) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, child := range t {
+			keys[k] = true
+			collectKeys(child, keys)
+		}
+	case []interface{}:
+		for _, child := range t {
+			collectKeys(child, keys)
+		}
+	}
+}
+
+func TestBuildExamQuestionsOmitsScoringFields(t *testing.T) {
+	raw, err := json.Marshal(buildExamQuestions(sampleAnswers(), true))
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	keys := map[string]bool{}
+	collectKeys(decoded, keys)
+
+	for _, k := range scoringKeys {
+		if keys[k] {
+			t.Errorf("payload exposes scoring field %q: %s", k, raw)
+		}
+	}
+}
+
+func TestBuildExamQuestionsAppliesOptionsOrder(t *testing.T) {
+	questions := buildExamQuestions(sampleAnswers(), true)
+	if len(questions) != 2 {
+		t.Fatalf("got %d questions, want 2", len(questions))
+	}
+
+	var got []int64
+	for _, opt := range questions[0].MainQuestion.Options {
+		got = append(got, opt.ID)
+	}
+	if want := []int64{3, 1, 4, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("main options = %v, want %v (deleted option dropped, order applied)", got, want)
+	}
+
+	open := questions[1].OpenQuestion
+	if open.Question != "What do you see?" || open.Options[0].OptionText != "Cat" {
+		t.Errorf("compat fields not populated: %+v", open)
+	}
+	if open.Images[0].ImageURL != "a.png" {
+		t.Errorf("images not sorted by display_order: %+v", open.Images)
+	}
+}
+
+func TestBuildExamQuestionsIgnoresOrderWhenShuffleOff(t *testing.T) {
+	questions := buildExamQuestions(sampleAnswers(), false)
+
+	var got []int64
+	for _, opt := range questions[0].MainQuestion.Options {
+		got = append(got, opt.ID)
+	}
+	if want := []int64{1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("main options = %v, want display_order %v", got, want)
+	}
+}
+
+func TestOrderOptions(t *testing.T) {
+	pos := func(v int) int { return v }
+	cases := []struct {
+		name    string
+		options []int
+		order   []int
+		want    []int
+	}{
+		{"no order keeps display order", []int{3, 1, 2}, nil, []int{1, 2, 3}},
+		{"full permutation", []int{1, 2, 3, 4}, []int{2, 4, 1, 3}, []int{2, 4, 1, 3}},
+		{"unknown positions skipped", []int{1, 2}, []int{4, 2, 3, 1}, []int{2, 1}},
+		{"unlisted options appended", []int{1, 2, 3, 4, 5}, []int{4, 3}, []int{4, 3, 1, 2, 5}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := orderOptions(c.options, pos, c.order); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("orderOptions(%v, %v) = %v, want %v", c.options, c.order, got, c.want)
+			}
+		})
+	}
+}
+
+func TestParseOptionsOrder(t *testing.T) {
+	cases := map[string][]int{
+		"[3,1,4,2]": {3, 1, 4, 2},
+		"2, 1":      {2, 1},
+		"":          nil,
+		"garbage":   nil,
+	}
+	for raw, want := range cases {
+		if got := parseOptionsOrder(raw); !reflect.DeepEqual(got, want) {
+			t.Errorf("parseOptionsOrder(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
+
+func TestShuffledOptionsOrderIsReproducible(t *testing.T) {
+	positions := []int{4, 2, 3, 1, 5}
+	first := shuffledOptionsOrder(42, "MAIN", 101, positions)
+	if again := shuffledOptionsOrder(42, "MAIN", 101, []int{1, 2, 3, 4, 5}); !reflect.DeepEqual(first, again) {
+		t.Errorf("same seed gave %v then %v", first, again)
+	}
+
+	sorted := append([]int(nil), first...)
+	sort.Ints(sorted)
+	if !reflect.DeepEqual(sorted, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("order %v is not a permutation of the positions", first)
+	}
+
+	differs := false
+	for attempt := int64(1); attempt <= 20 && !differs; attempt++ {
+		differs = !reflect.DeepEqual(first, shuffledOptionsOrder(attempt, "MAIN", 101, positions))
+	}
+	if !differs {
+		t.Error("order does not vary between attempts")
+	}
+}
+
+func TestFillOptionOrdersPinsSeededOrder(t *testing.T) {
+	db := newTestDB(t)
+	mustCreate(t, db, &models.AssessmentQuestion{ID: 101, QuestionTextEn: "Pick one", IsActive: true})
+	for i := int64(1); i <= 4; i++ {
+		mustCreate(t, db, &models.AssessmentQuestionOption{ID: i, QuestionID: 101, DisplayOrder: int(i), OptionTextEn: "x", IsActive: true})
+	}
+	// A row pinned earlier, and one with none.
+	mustCreate(t, db,
+		&models.AssessmentAnswer{ID: 1, AssessmentAttemptID: 7, QuestionSource: "MAIN", MainQuestionID: int64Ptr(101), QuestionSequence: 1, QuestionOptionsOrder: "[4,3,2,1]"},
+		&models.AssessmentAnswer{ID: 2, AssessmentAttemptID: 7, QuestionSource: "MAIN", MainQuestionID: int64Ptr(101), QuestionSequence: 2},
+	)
+	seeded, _ := json.Marshal(shuffledOptionsOrder(7, "MAIN", 101, []int{1, 2, 3, 4}))
+
+	stored := func() []string {
+		var orders []string
+		db.Model(&models.AssessmentAnswer{}).Order("id").Pluck("question_options_order", &orders)
+		return orders
+	}
+
+	answers, err := loadAttemptAnswers(db, 7)
+	if err != nil {
+		t.Fatalf("load answers: %v", err)
+	}
+	fillOptionOrders(db, answers)
+	if got := stored(); got[0] != "[4,3,2,1]" || got[1] != string(seeded) {
+		t.Errorf("orders = %v, want existing kept and empty filled with %s", got, seeded)
+	}
+	if answers[1].QuestionOptionsOrder != string(seeded) {
+		t.Errorf("answers not updated in place: %q", answers[1].QuestionOptionsOrder)
+	}
+
+	// Later loads never reshuffle.
+	answers, _ = loadAttemptAnswers(db, 7)
+	fillOptionOrders(db, answers)
+	if got := stored(); got[0] != "[4,3,2,1]" || got[1] != string(seeded) {
+		t.Errorf("reload changed orders: %v", got)
+	}
+}
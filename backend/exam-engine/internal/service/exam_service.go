@@ -1,14 +1,11 @@
 package service
 
 import (
-	"bytes"
 	"encoding/json"
 	"errors"
 	"exam-engine/internal/models"
 	"exam-engine/internal/repository"
 	"fmt"
-	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -16,7 +13,11 @@ import (
 	"gorm.io/gorm/clause"
 )
 
-type ExamService struct{}
+type ExamService struct {
+	// complete runs the completion pipeline; nil means completeAttempt.
+	// Tests replace it to observe when attempts are completed.
+	complete func(db *gorm.DB, attemptID int64, mode string) (*completionResult, error)
+}
 
 func NewExamService() *ExamService {
 	return &ExamService{}
@@ -40,29 +41,78 @@ type iatRegistrationScope struct {
 	StudentBoard       string
 }
 
-func (s *ExamService) GetExamQuestions(attemptID int64, studentID int64) ([]models.AssessmentAnswer, error) {
+// GetExamQuestions starts (or resumes) an attempt and returns its questions
+// as the candidate-facing payload together with the attempt's clock. Scoring
+// data never leaves this method. caller.UserID is the student; with the device
+// lock on, the paper carries a session token bound to the caller's device.
+func (s *ExamService) GetExamQuestions(attemptID int64, caller Caller) (*models.ExamPaper, error) {
 	db := repository.GetDB()
 
 	// 1. Security Check: Verify the attempt belongs to the requesting student
 	var attempt models.AssessmentAttempt
-	if err := db.Where("id = ? AND user_id = ?", attemptID, studentID).First(&attempt).Error; err != nil {
-		return nil, errors.New("assessment attempt not found or access denied")
+	if err := db.Where("id = ? AND user_id = ?", attemptID, caller.UserID).First(&attempt).Error; err != nil {
+		return nil, &ExamError{Code: ErrCodeAttemptNotFound, Message: "assessment attempt not found or access denied"}
 	}
 
-	// 1b. Ensure Started State
+	// 1b. Access Policy: status and unlock/expiry window
 	now := time.Now()
+	if err := checkAttemptAccess(attempt, now); err != nil {
+		fmt.Printf("[GetExamQuestions] DENIED: Attempt %d: %v\n", attempt.ID, err)
+		return nil, err
+	}
+
+	// 1c. Eligibility: payment, blocked/inactive user, blocked tenant
+	if err := s.checkEligibility(db, attempt); err != nil {
+		return nil, err
+	}
+
+	// 1d. Device Session: one active device per attempt
+	var sessionToken string
+	if deviceLockEnabled(db) {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			var err error
+			sessionToken, err = bindDevice(tx, attempt.ID, caller, false)
+			return err
+		})
+		if err != nil {
+			fmt.Printf("[GetExamQuestions] DENIED: Attempt %d: %v\n", attempt.ID, err)
+			return nil, err
+		}
+	}
+
+	// 1e. Ensure Started State
 
 	// Update Attempt to IN_PROGRESS if needed
 	// Fix: Handle both "NOT_STARTED" (Default) and "NOT_YET_STARTED" (Legacy/Seeded)
 	if attempt.Status == "NOT_STARTED" || attempt.Status == "NOT_YET_STARTED" {
 		attempt.Status = "IN_PROGRESS"
 		attempt.StartedAt = &now
-		if err := db.Model(&attempt).Updates(map[string]interface{}{
+		updates := map[string]interface{}{
 			"status":     "IN_PROGRESS",
 			"started_at": now,
-		}).Error; err != nil {
+		}
+		// Stamp the deadline from the level duration so the clock is owned by
+		// the server, not the browser.
+		if level, ok := s.attemptLevel(db, attempt); ok {
+			if deadline := levelDeadline(level, now); deadline != nil {
+				attempt.MustFinishBy = deadline
+				updates["must_finish_by"] = *deadline
+			}
+		}
+		if err := db.Model(&attempt).Updates(updates).Error; err != nil {
 			return nil, err
 		}
+	} else if attempt.Status == "IN_PROGRESS" && attempt.MustFinishBy == nil {
+		// Attempts started before deadlines were enforced: give them the full
+		// duration from now rather than timing them out on resume.
+		if level, ok := s.attemptLevel(db, attempt); ok {
+			if deadline := levelDeadline(level, now); deadline != nil {
+				attempt.MustFinishBy = deadline
+				if err := db.Model(&attempt).Update("must_finish_by", *deadline).Error; err != nil {
+					return nil, err
+				}
+			}
+		}
 	}
 
 	// Update Parent Session and Group Assessment (Always check to ensure consistency)
@@ -92,18 +142,10 @@ func (s *ExamService) GetExamQuestions(attemptID int64, studentID int64) ([]mode
 	}
 
 	// 2. Fetch Questions
-	var answers []models.AssessmentAnswer
-	result := db.Where("assessment_attempt_id = ?", attemptID).
-		Preload("MainQuestion").
-		Preload("MainQuestion.Options").
-		Preload("OpenQuestion").
-		Preload("OpenQuestion.Options").
-		Preload("OpenQuestion.Images").
-		Order("question_sequence ASC").
-		Find(&answers)
-
-	if result.Error != nil {
-		return nil, result.Error
+	shuffle := shuffleOptionsEnabled(db)
+	answers, err := loadAttemptAnswers(db, attemptID)
+	if err != nil {
+		return nil, err
 	}
 
 	// 3. Fallback Generation (Self-Healing)
@@ -114,7 +156,7 @@ func (s *ExamService) GetExamQuestions(attemptID int64, studentID int64) ([]mode
 			if s.isIATGenLevel2Attempt(db, attempt) {
 				fmt.Printf("[GetExamQuestions - IAT] Attempt %d is configured for IAT Gen; skipping ACI self-healing generation.\n", attempt.ID)
 				s.markAttemptAsIATGen(db, attempt.ID)
-				return answers, nil
+				return &models.ExamPaper{Questions: buildExamQuestions(answers, shuffle), Timing: attemptTiming(attempt, time.Now()), SessionToken: sessionToken}, nil
 			}
 
 			fmt.Printf("[GetExamQuestions - Fallback] No questions found for Attempt %d (Level 2). Attempting self-healing generation...\n", attempt.ID)
@@ -265,17 +307,9 @@ func (s *ExamService) GetExamQuestions(attemptID int64, studentID int64) ([]mode
 					}
 
 					// Re-fetch questions after generation
-					result = db.Where("assessment_attempt_id = ?", attemptID).
-						Preload("MainQuestion").
-						Preload("MainQuestion.Options").
-						Preload("OpenQuestion").
-						Preload("OpenQuestion.Options").
-						Preload("OpenQuestion.Images").
-						Order("question_sequence ASC").
-						Find(&answers)
-
-					if result.Error != nil {
-						return nil, result.Error
+					answers, err = loadAttemptAnswers(db, attemptID)
+					if err != nil {
+						return nil, err
 					}
 				} else {
 					fmt.Printf("[GetExamQuestions - Fallback Error] Cannot generate questions for Attempt %d: Trait ID is nil. Checked: attempt.DominantTraitID, session metadata, previous completed attempts, and any attempt in session %d.\n", attempt.ID, attempt.AssessmentSessionID)
@@ -284,7 +318,44 @@ func (s *ExamService) GetExamQuestions(attemptID int64, studentID int64) ([]mode
 		}
 	}
 
-	return answers, nil
+	// Pin the per-candidate option order on every row that has none yet,
+	// whatever the attempt status: the generating services leave it empty.
+	if shuffle {
+		fillOptionOrders(db, answers)
+	}
+
+	return &models.ExamPaper{
+		Questions:    buildExamQuestions(answers, shuffle),
+		Timing:       attemptTiming(attempt, time.Now()),
+		SessionToken: sessionToken,
+	}, nil
+}
+
+// attemptLevel loads the assessment level an attempt belongs to.
+func (s *ExamService) attemptLevel(db *gorm.DB, attempt models.AssessmentAttempt) (models.AssessmentLevel, bool) {
+	var level models.AssessmentLevel
+	if attempt.AssessmentLevelID == nil {
+		return level, false
+	}
+	if err := db.First(&level, *attempt.AssessmentLevelID).Error; err != nil {
+		return level, false
+	}
+	return level, true
+}
+
+// loadAttemptAnswers fetches an attempt's answer slots in question order with
+// the question, option and media relations the payload builder needs.
+func loadAttemptAnswers(db *gorm.DB, attemptID int64) ([]models.AssessmentAnswer, error) {
+	var answers []models.AssessmentAnswer
+	err := db.Where("assessment_attempt_id = ?", attemptID).
+		Preload("MainQuestion").
+		Preload("MainQuestion.Options").
+		Preload("OpenQuestion").
+		Preload("OpenQuestion.Options").
+		Preload("OpenQuestion.Images").
+		Order("question_sequence ASC").
+		Find(&answers).Error
+	return answers, err
 }
 
 // generateReportNumber mints the next OBI report number for a session using the
@@ -320,29 +391,76 @@ func (s *ExamService) generateReportNumber(tx *gorm.DB, session models.Assessmen
 	return fmt.Sprintf("%s%03d", reportPrefix, seqNum)
 }
 
-func (s *ExamService) SubmitAnswer(req models.StudentAnswer) error {
+// SubmitAnswer saves one answer. caller.UserID is the authenticated caller;
+// it is 0 only when authentication is disabled, in which case ownership is
+// not checked.
+func (s *ExamService) SubmitAnswer(req models.StudentAnswer, caller Caller) (*models.AnswerOutcome, error) {
 	db := repository.GetDB()
 
-	// 1. Find the answer record
+	var attemptID int64
+	outcome := &models.AnswerOutcome{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		answerRecord, err := findAnswerRecord(tx, req)
+		if err != nil {
+			return err
+		}
+		attemptID = answerRecord.AssessmentAttemptID
+		outcome.AssessmentAnswerID = answerRecord.ID
+
+		attempt, err := s.loadOwnedAttempt(tx, attemptID, caller)
+		if err != nil {
+			return err
+		}
+		// A retry of an applied answer gets its outcome back even when that
+		// answer completed the attempt or its time has run out since.
+		if isDuplicateSubmission(answerRecord, req) {
+			fmt.Printf("[SubmitAnswer] DUPLICATE: Answer ID=%d key=%s ignored\n", answerRecord.ID, req.IdempotencyKey)
+			outcome.Outcome = answerDuplicate
+			return nil
+		}
+		if err := s.checkAnswerable(tx, attempt, caller); err != nil {
+			return err
+		}
+		s.ensureAttemptInProgress(tx, attempt)
+
+		outcome.Outcome, err = s.applyAnswer(tx, answerRecord, req)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if outcome.Outcome == answerApplied {
+		if _, err := s.completeIfFullyAnswered(db, attemptID); err != nil {
+			return nil, err
+		}
+	}
+	return outcome, nil
+}
+
+// findAnswerRecord locates and row-locks the assessment_answers row an answer
+// is for, so concurrent retries for the same question apply one at a time.
+func findAnswerRecord(tx *gorm.DB, req models.StudentAnswer) (*models.AssessmentAnswer, error) {
 	var answerRecord models.AssessmentAnswer
 	var query *gorm.DB
+	tx = tx.Clauses(clause.Locking{Strength: "UPDATE"})
 
 	// DEFINITIVE FIX: Use Primary Key of assessment_answers if available (Precision Update)
 	if req.AssessmentAnswerID > 0 {
-		query = db.First(&answerRecord, req.AssessmentAnswerID)
+		query = tx.First(&answerRecord, req.AssessmentAnswerID)
 	} else {
 		// Fallback Logic (Legacy)
 		// Improve Precision: Use QuestionSource if available
 		if req.QuestionSource != "" {
 			if req.QuestionSource == "OPEN" {
-				query = db.Where("assessment_attempt_id = ? AND open_question_id = ?", req.AttemptID, req.QuestionID)
+				query = tx.Where("assessment_attempt_id = ? AND open_question_id = ?", req.AttemptID, req.QuestionID)
 			} else {
 				// Assume MAIN if not OPEN (or explicit MAIN)
-				query = db.Where("assessment_attempt_id = ? AND main_question_id = ?", req.AttemptID, req.QuestionID)
+				query = tx.Where("assessment_attempt_id = ? AND main_question_id = ?", req.AttemptID, req.QuestionID)
 			}
 		} else {
 			// Fallback for legacy requests (Potentially ambiguous)
-			query = db.Where("assessment_attempt_id = ? AND (main_question_id = ? OR open_question_id = ?)", req.AttemptID, req.QuestionID, req.QuestionID)
+			query = tx.Where("assessment_attempt_id = ? AND (main_question_id = ? OR open_question_id = ?)", req.AttemptID, req.QuestionID, req.QuestionID)
 		}
 
 		query = query.First(&answerRecord)
@@ -351,88 +469,165 @@ func (s *ExamService) SubmitAnswer(req models.StudentAnswer) error {
 	if query.Error != nil {
 		fmt.Printf("[SubmitAnswer] ERROR: Record not found. AttemptID=%d QuestionID=%d AnswerID=%d Source=%s Error=%v\n",
 			req.AttemptID, req.QuestionID, req.AssessmentAnswerID, req.QuestionSource, query.Error)
-		return errors.New("question not found for this attempt")
+		return nil, &ExamError{Code: ErrCodeQuestionNotFound, Message: "question not found for this attempt"}
 	}
 
 	fmt.Printf("[SubmitAnswer] DEBUG: Found Record ID=%d Status=%s. ReqQuestionID=%d MainQ=%v OpenQ=%v\n",
 		answerRecord.ID, answerRecord.Status, req.QuestionID, answerRecord.MainQuestionID, answerRecord.OpenQuestionID)
 
-	// 2. Update the record
-	if answerRecord.MainQuestionID != nil && *answerRecord.MainQuestionID == req.QuestionID {
-		answerRecord.MainOptionID = &req.SelectedOption
+	return &answerRecord, nil
+}
+
+// loadOwnedAttempt loads an attempt owned by caller.UserID (0 = unchecked).
+func (s *ExamService) loadOwnedAttempt(tx *gorm.DB, attemptID int64, caller Caller) (models.AssessmentAttempt, error) {
+	userID := caller.UserID
+	var attempt models.AssessmentAttempt
+	if err := tx.First(&attempt, attemptID).Error; err != nil {
+		return attempt, &ExamError{Code: ErrCodeAttemptNotFound, Message: "assessment attempt not found"}
+	}
+	// Someone else's attempt looks exactly like a missing one.
+	if userID != 0 && attempt.UserID != userID {
+		fmt.Printf("[SubmitAnswer] REJECTED: Attempt %d does not belong to User %d\n", attempt.ID, userID)
+		return attempt, &ExamError{Code: ErrCodeAttemptNotFound, Message: "assessment attempt not found"}
+	}
+	return attempt, nil
+}
+
+// checkAnswerable rejects answers the access policy forbids (locked, expired,
+// completed), that arrive after the attempt's deadline, or that come from a
+// device without the attempt's session. Duplicates are let through before
+// this check (see isDuplicateSubmission).
+func (s *ExamService) checkAnswerable(tx *gorm.DB, attempt models.AssessmentAttempt, caller Caller) error {
+	if err := checkAttemptAccess(attempt, time.Now()); err != nil {
+		fmt.Printf("[SubmitAnswer] REJECTED: Attempt %d: %v\n", attempt.ID, err)
+		return err
+	}
+	if isPastDeadline(attempt, time.Now()) {
+		fmt.Printf("[SubmitAnswer] REJECTED: Attempt %d past must_finish_by %v\n", attempt.ID, *attempt.MustFinishBy)
+		return &ExamError{
+			Code:    ErrCodeTimeUp,
+			Message: "time limit for this attempt has passed",
+			Details: map[string]interface{}{"must_finish_by": attempt.MustFinishBy},
+		}
+	}
+	if err := enforceDeviceLock(tx, attempt, caller); err != nil {
+		fmt.Printf("[SubmitAnswer] REJECTED: Attempt %d: %v\n", attempt.ID, err)
+		return err
+	}
+	return nil
+}
+
+// ensureAttemptInProgress is the self-healing step for answers that arrive
+// while the attempt (or its session) is still NOT_STARTED, e.g. when the
+// start call raced the first answer.
+func (s *ExamService) ensureAttemptInProgress(tx *gorm.DB, attempt models.AssessmentAttempt) {
+	if attempt.Status != "NOT_STARTED" && attempt.Status != "NOT_YET_STARTED" {
+		return
+	}
 
-		// Fetch Option & Question Details
-		var option models.AssessmentQuestionOption
-		if err := db.First(&option, req.SelectedOption).Error; err == nil {
-			answerRecord.AnswerScore = option.ScoreValue
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":     "IN_PROGRESS",
+		"started_at": now,
+	}
+	if level, ok := s.attemptLevel(tx, attempt); ok {
+		if deadline := levelDeadline(level, now); deadline != nil {
+			updates["must_finish_by"] = *deadline
+		}
+	}
+	tx.Model(&models.AssessmentAttempt{}).Where("id = ?", attempt.ID).Updates(updates)
 
-			// Detect Flags based on Question Category
-			var question models.AssessmentQuestion
-			if err := db.First(&question, *answerRecord.MainQuestionID).Error; err == nil {
+	// Also update Session if needed (Simplified)
+	var sess models.AssessmentSession
+	if err := tx.First(&sess, attempt.AssessmentSessionID).Error; err == nil {
+		if sess.Status == "NOT_STARTED" || sess.Status == "NOT_YET_STARTED" {
+			tx.Model(&sess).Updates(map[string]interface{}{
+				"status":     "IN_PROGRESS",
+				"started_at": now,
+			})
+		}
+	}
+}
 
-				// Default: Assume Sincere (2)
-				answerRecord.SincerityFlag = 2
+// applyAnswer records the selected option, sincerity flags and timing on an
+// answer row. Retries (same idempotency key) are reported as DUPLICATE without
+// touching the row; out-of-order writes are rejected as STALE_ANSWER.
+func (s *ExamService) applyAnswer(tx *gorm.DB, answerRecord *models.AssessmentAnswer, req models.StudentAnswer) (string, error) {
+	answerMeta, submission := decodeAnswerMetadata(answerRecord.Metadata)
+	switch classifySubmission(submission, req) {
+	case answerDuplicate:
+		fmt.Printf("[SubmitAnswer] DUPLICATE: Answer ID=%d key=%s ignored\n", answerRecord.ID, req.IdempotencyKey)
+		return answerDuplicate, nil
+	case answerStale:
+		fmt.Printf("[SubmitAnswer] STALE: Answer ID=%d seq=%d older than applied seq=%d\n", answerRecord.ID, req.ClientSeq, submission.ClientSeq)
+		return "", &ExamError{
+			Code:    ErrCodeStaleAnswer,
+			Message: "a newer answer for this question was already saved",
+			Details: map[string]interface{}{
+				"applied_client_seq": submission.ClientSeq,
+				"applied_client_ts":  submission.ClientTs,
+			},
+		}
+	}
+	answerMeta["submission"] = recordSubmission(submission, req)
+	delete(answerMeta, "selected_option_ids")
+	delete(answerMeta, "correct")
 
-				// 1. Attention Check Fail
-				if question.Category == "ATTENTION_CHECK" {
-					// For attention checks, the option must be marked IsCorrect=true to pass.
-					if !option.IsCorrect {
-						answerRecord.IsAttentionFail = true
-						answerRecord.SincerityFlag = 1 // Not Sincere
-					} else {
-						answerRecord.IsAttentionFail = false
-						answerRecord.SincerityFlag = 2 // Sincere
-					}
-				}
+	typed, err := applyTypedResponse(tx, answerRecord, answerMeta, req)
+	if err != nil {
+		return "", err
+	}
 
-				// 2. Distraction Chosen
-				// If the question category is DISTRACTION, any selection is considered "choosing distraction"
-				// OR if the system supports Distractor Options within normal questions (not implemented here yet).
-				// Assuming 'DISTRACTION' category represents questions designed to test focus/distractibility.
-				if question.Category == "DISTRACTION" {
-					// Logic: If they answer a distraction question, does it count as chosen?
-					// Usually Distraction questions are "Do not answer this" or "Select 'Strongly Disagree'".
-					// Without specific logic, we'll assume if it's a Distraction Category,
-					// and they selected a "Wrong" or specific option?
-					// For now: If Category is Distraction, set flag.
-					answerRecord.IsDistractionChosen = true
-					answerRecord.SincerityFlag = 1
-				}
+	switch {
+	case typed:
+		// Text, number, essay and ranking answers carry no selected option.
+
+	case answerRecord.MainQuestionID != nil && *answerRecord.MainQuestionID == req.QuestionID:
+		var question models.AssessmentQuestion
+		tx.First(&question, *answerRecord.MainQuestionID)
+		qMeta := parseQuestionMeta(question.Metadata)
+
+		selected, err := selectedOptionIDs(req, qMeta)
+		if err != nil {
+			return "", err
+		}
+		answerRecord.IsMultipleSelection = qMeta.MultiSelect
+
+		if qMeta.MultiSelect {
+			if err := applyMainMultiSelect(tx, answerRecord, answerMeta, question, qMeta, selected); err != nil {
+				return "", err
 			}
+		} else if err := applyMainSingleSelect(tx, answerRecord, question, selected[0]); err != nil {
+			return "", err
 		}
 
-	} else if answerRecord.OpenQuestionID != nil && *answerRecord.OpenQuestionID == req.QuestionID {
-		answerRecord.OpenOptionID = &req.SelectedOption
+	case answerRecord.OpenQuestionID != nil && *answerRecord.OpenQuestionID == req.QuestionID:
+		var question models.OpenQuestion
+		tx.First(&question, *answerRecord.OpenQuestionID)
+		qMeta := parseQuestionMeta(question.Metadata)
+
+		selected, err := selectedOptionIDs(req, qMeta)
+		if err != nil {
+			return "", err
+		}
+		answerRecord.IsMultipleSelection = qMeta.MultiSelect
+
+		if qMeta.MultiSelect {
+			if err := applyOpenMultiSelect(tx, answerRecord, answerMeta, question, qMeta, selected); err != nil {
+				return "", err
+			}
+		} else if err := applyOpenSingleSelect(tx, answerRecord, answerMeta, question, qMeta, selected[0]); err != nil {
+			return "", err
+		}
 
 		// Open Question Logic
 		// Default Sincere for Open Questions
 		answerRecord.SincerityFlag = 2
 	}
 
-	// Self-Healing: If Attempt Status is still NOT_STARTED (e.g. initial start race condition), force it to IN_PROGRESS
-	// We do this asynchronously or simply fire-and-forget update
-	go func(attemptID int64) {
-		repoDB := repository.GetDB()
-		var att models.AssessmentAttempt
-		if err := repoDB.First(&att, attemptID).Error; err == nil {
-			if att.Status == "NOT_STARTED" || att.Status == "NOT_YET_STARTED" {
-				repoDB.Model(&att).Updates(map[string]interface{}{
-					"status":     "IN_PROGRESS",
-					"started_at": time.Now(),
-				})
-				// Also update Session if needed (Simplified)
-				var sess models.AssessmentSession
-				if err := repoDB.First(&sess, att.AssessmentSessionID).Error; err == nil {
-					if sess.Status == "NOT_STARTED" || sess.Status == "NOT_YET_STARTED" {
-						repoDB.Model(&sess).Updates(map[string]interface{}{
-							"status":     "IN_PROGRESS",
-							"started_at": time.Now(),
-						})
-					}
-				}
-			}
-		}
-	}(req.AttemptID)
+	if b, err := json.Marshal(answerMeta); err == nil {
+		answerRecord.Metadata = string(b)
+	}
 
 	// Update fields
 	answerRecord.TimeSpentSeconds += req.TimeTaken
@@ -443,689 +638,89 @@ func (s *ExamService) SubmitAnswer(req models.StudentAnswer) error {
 	fmt.Printf("[SubmitAnswer] DEBUG: Saving Record ID=%d Status=%s MainOption=%v OpenOption=%v\n",
 		answerRecord.ID, answerRecord.Status, answerRecord.MainOptionID, answerRecord.OpenOptionID)
 
-	if err := db.Save(&answerRecord).Error; err != nil {
+	if err := tx.Save(answerRecord).Error; err != nil {
 		fmt.Printf("[SubmitAnswer] ERROR: Save Failed for ID=%d Error=%v\n", answerRecord.ID, err)
-		return err
+		return "", err
 	}
 	fmt.Printf("[SubmitAnswer] SUCCESS: Saved Answer ID=%d\n", answerRecord.ID)
 
+	return answerApplied, nil
+}
+
+// completeIfFullyAnswered runs the completion pipeline once every question of
+// the attempt has been answered. It reports whether the attempt is completed.
+func (s *ExamService) completeIfFullyAnswered(db *gorm.DB, attemptID int64) (bool, error) {
 	// Check if this was the last question
 	var totalCounts int64
 	var answeredCounts int64
 
 	// Count total rows in answers table for this attempt (Total Questions)
-	db.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ?", answerRecord.AssessmentAttemptID).Count(&totalCounts)
+	db.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ?", attemptID).Count(&totalCounts)
 
 	// Count answered rows
-	db.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ? AND status = ?", answerRecord.AssessmentAttemptID, "ANSWERED").Count(&answeredCounts)
-
-	if totalCounts > 0 && answeredCounts == totalCounts {
-		var sessionCompleted bool
-		var completedUserID int64
-		var nextLevelNum int
-
-		// Start Transaction (Concurrency Fix)
-		err := db.Transaction(func(tx *gorm.DB) error {
-			now := time.Now()
-
-			// 1. Lock Attempt Row & Check Idempotency
-			var lockedAttempt models.AssessmentAttempt
-			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&lockedAttempt, answerRecord.AssessmentAttemptID).Error; err != nil {
-				return err
-			}
-
-			if lockedAttempt.Status == "COMPLETED" {
-				fmt.Printf("[SubmitAnswer] IDEMPOTENCY HIT: Attempt %d already completed.\n", lockedAttempt.ID)
-				return nil
-			}
-
-			// Capture User ID for notification
-			completedUserID = lockedAttempt.UserID
-
-			// Fetch Level Context First
-			var currentLevel models.AssessmentLevel
-			if err := tx.First(&currentLevel, "id = ?", answerRecord.AssessmentLevelID).Error; err != nil {
-				return err
-			}
-
-			scoreMap := make(map[string]float64)
-			var totalScore float64
-			var dominantFactor string
-			var traitID *int64
-			var agileOrderedData interface{}
-
-			// --- Scoring Logic Based on Level ---
-			if currentLevel.LevelNumber == 1 || currentLevel.Name == "Level 1" || currentLevel.PatternType == "DISC" {
-				// ** Level 1: DISC Logic (Option Based) **
-				type ScoreResult struct {
-					DiscFactor string
-					Total      float64
-				}
-				var scores []ScoreResult
-				tx.Raw(`
-					SELECT o.disc_factor, SUM(o.score_value) as total 
-					FROM assessment_answers a 
-					JOIN assessment_question_options o ON a.main_option_id = o.id 
-					WHERE a.assessment_attempt_id = ? 
-					GROUP BY o.disc_factor
-				`, answerRecord.AssessmentAttemptID).Scan(&scores)
-
-				for _, s := range scores {
-					totalScore += s.Total
-					if s.DiscFactor != "" {
-						scoreMap[s.DiscFactor] = s.Total
-					}
-				}
-
-				// Determine Dominant Factor (pure-trait aware - see disc_trait.go).
-				dominantFactor = ResolveDominantFactor(scoreMap)
-
-				// Find Dominant Trait ID
-				if dominantFactor != "" {
-					var trait models.PersonalityTrait
-					if err := tx.Where("code = ?", dominantFactor).First(&trait).Error; err == nil {
-						tID := trait.ID
-						traitID = &tID
-					}
-				}
-
-			} else if currentLevel.LevelNumber == 2 || currentLevel.Name == "Level 2" {
-				// ** Level 2: Agile Logic (Question Category Based) **
-				// Categories: Commitment, Focus, Openness, Respect, Courage
-				type AgileScoreResult struct {
-					Category string
-					Total    float64
-				}
-				var agileScores []AgileScoreResult
-				tx.Raw(`
-					SELECT UPPER(q.category) as category, SUM(a.answer_score) as total
-					FROM assessment_answers a
-					JOIN assessment_questions q ON a.main_question_id = q.id
-					WHERE a.assessment_attempt_id = ?
-					GROUP BY UPPER(q.category)
-				`, answerRecord.AssessmentAttemptID).Scan(&agileScores)
-
-				// Define struct with specific field order (Total last)
-				var orderedAgile struct {
-					Commitment float64 `json:"Commitment"`
-					Courage    float64 `json:"Courage"`
-					Focus      float64 `json:"Focus"`
-					Openness   float64 `json:"Openness"`
-					Respect    float64 `json:"Respect"`
-					Total      float64 `json:"total"`
-				}
-
-				for _, s := range agileScores {
-					totalScore += s.Total
-					// Populate struct fields
-					switch s.Category {
-					case "COMMITMENT":
-						orderedAgile.Commitment = s.Total
-						scoreMap["Commitment"] = s.Total
-					case "COURAGE":
-						orderedAgile.Courage = s.Total
-						scoreMap["Courage"] = s.Total
-					case "FOCUS":
-						orderedAgile.Focus = s.Total
-						scoreMap["Focus"] = s.Total
-					case "OPENNESS":
-						orderedAgile.Openness = s.Total
-						scoreMap["Openness"] = s.Total
-					case "RESPECT":
-						orderedAgile.Respect = s.Total
-						scoreMap["Respect"] = s.Total
-					default:
-						if s.Category != "" {
-							scoreMap[s.Category] = s.Total
-						}
-					}
-				}
-				orderedAgile.Total = totalScore
-				agileOrderedData = orderedAgile
-			}
-
-			// Add Total to Map
-			scoreMap["total"] = totalScore
-
-			// --- Sincerity Index Calculation ---
-			var sincerityStats struct {
-				AttentionFails     int64
-				DistractionsChosen int64
-				TotalQuestions     int64
-			}
-
-			tx.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ?", answerRecord.AssessmentAttemptID).
-				Select("COUNT(*) FILTER (WHERE is_attention_fail = true) as attention_fails, COUNT(*) FILTER (WHERE is_distraction_chosen = true) as distractions_chosen, COUNT(*) as total_questions").
-				Scan(&sincerityStats)
-
-			sincerityIndex := 100.0
-			sincerityIndex -= (float64(sincerityStats.AttentionFails) * 20.0)
-			sincerityIndex -= (float64(sincerityStats.DistractionsChosen) * 10.0)
-			if sincerityIndex < 0 {
-				sincerityIndex = 0
-			}
-
-			var sincerityClass string
-			if sincerityIndex >= 80 {
-				sincerityClass = "SINCERE"
-			} else if sincerityIndex >= 50 {
-				sincerityClass = "BORDERLINE"
-			} else {
-				sincerityClass = "NOT_SINCERE"
-			}
+	db.Model(&models.AssessmentAnswer{}).Where("assessment_attempt_id = ? AND status = ?", attemptID, "ANSWERED").Count(&answeredCounts)
 
-			// --- Metadata Update ---
-			// Re-use lockedAttempt for metadata as it's fresh
-			metaMap := make(map[string]interface{})
-			if lockedAttempt.Metadata != "" && lockedAttempt.Metadata != "{}" {
-				json.Unmarshal([]byte(lockedAttempt.Metadata), &metaMap)
-			}
-
-			metaMap["overall_sincerity"] = sincerityIndex // Always store sincerity
-
-			if currentLevel.LevelNumber == 1 || currentLevel.PatternType == "DISC" || currentLevel.Name == "Level 1" {
-				metaMap["disc_scores"] = scoreMap
-			} else if currentLevel.LevelNumber == 2 || currentLevel.Name == "Level 2" {
-				if agileOrderedData != nil {
-					metaMap["agile_scores"] = agileOrderedData
-				} else {
-					metaMap["agile_scores"] = scoreMap
-				}
-			} else if currentLevel.LevelNumber == 3 {
-				metaMap["level3_scores"] = scoreMap
-			} else if currentLevel.LevelNumber == 4 {
-				metaMap["level4_scores"] = scoreMap
-			}
-
-			updatedMeta, _ := json.Marshal(metaMap)
-
-			// --- Update Current Attempt ---
-			updates := map[string]interface{}{
-				"status":          "COMPLETED",
-				"completed_at":    now,
-				"metadata":        string(updatedMeta),
-				"total_score":     totalScore,
-				"sincerity_index": sincerityIndex,
-				"sincerity_class": sincerityClass,
-			}
-			// Only update dominant_trait_id if it was calculated (Level 1)
-			if traitID != nil {
-				updates["dominant_trait_id"] = traitID
-			}
-
-			if err := tx.Model(&models.AssessmentAttempt{}).Where("id = ?", answerRecord.AssessmentAttemptID).Updates(updates).Error; err != nil {
-				return err
-			}
-
-			// --- 🟢 ASSIGN REPORT NUMBER AT LEVEL 1 ---
-			// As soon as Level 1 (Behavioural/DISC) completes, mint the OBI
-			// report number so the Level 1 report can be downloaded with a real
-			// reference while later levels are still pending. The ACI / Level-3/4
-			// score snapshot is backfilled when the whole session completes (see
-			// the completion block below). Idempotent: only creates if absent.
-			if currentLevel.LevelNumber == 1 || currentLevel.PatternType == "DISC" || currentLevel.Name == "Level 1" {
-				var existingReport models.AssessmentReports
-				if err := tx.Where("assessment_session_id = ?", answerRecord.AssessmentSessionID).First(&existingReport).Error; err != nil {
-					var repSession models.AssessmentSession
-					if err := tx.First(&repSession, answerRecord.AssessmentSessionID).Error; err == nil {
-						reportNumber := s.generateReportNumber(tx, repSession, now)
-						discJSON := "{}"
-						if val, ok := metaMap["disc_scores"]; ok {
-							if b, e := json.Marshal(val); e == nil {
-								discJSON = string(b)
-							}
-						}
-						earlyReport := models.AssessmentReports{
-							AssessmentSessionID: repSession.ID,
-							ReportNumber:        reportNumber,
-							GeneratedAt:         now,
-							DiscScores:          discJSON,
-							AgileScores:         "{}",
-							Level3Scores:        "{}",
-							Level4Scores:        "{}",
-							OverallSincerity:    sincerityIndex,
-							DominantTraitID:     traitID,
-							Metadata:            "{}",
-						}
-						// Isolate the insert in a savepoint so a unique-number race
-						// (two students finishing Level 1 at the same instant) can
-						// never abort the whole answer-submission transaction - it
-						// just skips the early row and falls back to creating it at
-						// completion.
-						createErr := tx.Transaction(func(tx2 *gorm.DB) error {
-							return tx2.Create(&earlyReport).Error
-						})
-						if createErr != nil {
-							fmt.Printf("[SubmitAnswer] Skipped early L1 Assessment Report (will create at completion): %v\n", createErr)
-						} else {
-							fmt.Printf("[SubmitAnswer] Early L1 Assessment Report created: %s\n", reportNumber)
-						}
-					}
-				}
-			}
-
-			// 5. Next Level Setup (Level 2 Generation)
-			var nextLevel models.AssessmentLevel
-			var nextAttempt models.AssessmentAttempt
-			hasNextLevel := false
-
-			// Advance only through the levels this candidate was actually
-			// SCHEDULED for - i.e. the next mandatory level they already have an
-			// attempt for. We deliberately do NOT auto-create attempts for
-			// mandatory levels that weren't assigned to this registration (e.g.
-			// ACI when only Behavioural + IAT Gen + Metaphor were scheduled), so
-			// the exam and the report follow exactly the assigned set. A level
-			// enabled after registration must be added via re-assignment.
-			nextLevelErr := tx.
-				Table("assessment_levels").
-				Select("assessment_levels.*").
-				Joins("JOIN assessment_attempts aa ON aa.assessment_level_id = assessment_levels.id AND aa.assessment_session_id = ?", answerRecord.AssessmentSessionID).
-				Where("assessment_levels.level_number > ? AND assessment_levels.is_mandatory = ?", currentLevel.LevelNumber, true).
-				Order("assessment_levels.level_number ASC").
-				First(&nextLevel).Error
-
-			if nextLevelErr == nil {
-				// The join guarantees this candidate has an attempt for the
-				// level; load it so we can unlock it.
-				if attemptErr := tx.Where("assessment_session_id = ? AND assessment_level_id = ?", answerRecord.AssessmentSessionID, nextLevel.ID).First(&nextAttempt).Error; attemptErr != nil {
-					fmt.Printf("[SubmitAnswer] Next-level attempt lookup failed (level %d): %v\n", nextLevel.LevelNumber, attemptErr)
-				}
-
-				if nextAttempt.ID != 0 {
-
-					// CASE A: Next Level Exists AND User has (or now has) an attempt -> Unlock it
-					hasNextLevel = true
-
-					unlockAt := now.Add(time.Duration(nextLevel.UnlockAfterHours) * time.Hour)
-					startWindow := 72
-					if nextLevel.StartWithinHours > 0 {
-						startWindow = nextLevel.StartWithinHours
-					}
-					expiresAt := unlockAt.Add(time.Duration(startWindow) * time.Hour)
-
-					tx.Model(&nextAttempt).Updates(map[string]interface{}{
-						"unlock_at":  unlockAt,
-						"expires_at": expiresAt,
-					})
-
-					nextLevelNum = int(nextLevel.LevelNumber)
-
-					// Generate Questions for Next Level (Trait Based for Level 2)
-					if nextLevel.LevelNumber == 2 && traitID != nil {
-						if s.isIATGenLevel2Attempt(tx, nextAttempt) {
-							fmt.Printf("[SubmitAnswer] Level 2 Attempt %d configured for IAT Gen. Skipping ACI generation.\n", nextAttempt.ID)
-							s.markAttemptAsIATGen(tx, nextAttempt.ID)
-							return nil
-						}
-
-						// 1. Fetch Session/Registration Metadata for board info
-						var session models.AssessmentSession
-						var studentBoard string = ""
-
-						if err := tx.First(&session, nextAttempt.AssessmentSessionID).Error; err == nil {
-							var meta map[string]interface{}
-							if session.Metadata != "" && session.Metadata != "{}" {
-								if err := json.Unmarshal([]byte(session.Metadata), &meta); err == nil {
-									if val, ok := meta["studentBoard"]; ok {
-										if v, ok := val.(string); ok {
-											studentBoard = v
-										}
-									}
-								}
-							}
-						}
-
-						// Fallback to Registration metadata for board
-						if studentBoard == "" {
-							var reg models.Registration
-							if err := tx.First(&reg, nextAttempt.RegistrationID).Error; err == nil {
-								var regMeta map[string]interface{}
-								if reg.Metadata != "" && reg.Metadata != "{}" {
-									if err := json.Unmarshal([]byte(reg.Metadata), &regMeta); err == nil {
-										if val, ok := regMeta["studentBoard"]; ok {
-											if v, ok := val.(string); ok {
-												studentBoard = v
-											}
-										}
-									}
-								}
-							}
-						}
-
-						// 2. Clear existing generic questions (if any)
-						tx.Exec("DELETE FROM assessment_answers WHERE assessment_attempt_id = ?", nextAttempt.ID)
-
-						// 3. Insert new questions based on Trait (NO set_number for Level 2, matching admin-service)
-						var program models.Program
-						tx.First(&program, nextAttempt.ProgramID)
-
-						var query string
-						var args []interface{}
-
-						if program.Code == "SCHOOL_STUDENT" && studentBoard != "" {
-							// Balanced Category Selection with Board Priority
-							query = `
-								INSERT INTO assessment_answers (
-									assessment_attempt_id, assessment_session_id, user_id, registration_id, program_id, assessment_level_id, 
-									main_question_id, question_source, status, question_sequence, created_at, updated_at
-								)
-								SELECT ?, ?, ?, ?, ?, ?, id, 'MAIN', 'NOT_ANSWERED', ROW_NUMBER() OVER (ORDER BY RANDOM()), NOW(), NOW()
-								FROM (
-									WITH CombinedQuestions AS (
-										SELECT id, category, 1 as priority
-										FROM assessment_questions 
-										WHERE assessment_level_id = ? AND personality_trait_id = ? AND board = ? AND is_active = true AND is_deleted = false
-										UNION ALL
-										SELECT id, category, 2 as priority
-										FROM assessment_questions 
-										WHERE assessment_level_id = ? AND personality_trait_id = ? AND is_active = true AND is_deleted = false
-									)
-									SELECT id, ROW_NUMBER() OVER (PARTITION BY UPPER(category) ORDER BY priority, RANDOM()) as rnk
-									FROM CombinedQuestions
-									WHERE UPPER(category) IN ('COMMITMENT', 'COURAGE', 'FOCUS', 'OPENNESS', 'RESPECT')
-								) t
-								WHERE rnk <= 5
-							`
-							args = []interface{}{
-								nextAttempt.ID, nextAttempt.AssessmentSessionID, nextAttempt.UserID, nextAttempt.RegistrationID, nextAttempt.ProgramID, nextLevel.ID,
-								nextLevel.ID, *traitID, studentBoard,
-								nextLevel.ID, *traitID,
-							}
-						} else {
-							// Balanced Category Selection (Generic Trait)
-							query = `
-								INSERT INTO assessment_answers (
-									assessment_attempt_id, assessment_session_id, user_id, registration_id, program_id, assessment_level_id, 
-									main_question_id, question_source, status, question_sequence, created_at, updated_at
-								)
-								SELECT ?, ?, ?, ?, ?, ?, id, 'MAIN', 'NOT_ANSWERED', ROW_NUMBER() OVER (ORDER BY RANDOM()), NOW(), NOW()
-								FROM (
-									SELECT id, ROW_NUMBER() OVER (PARTITION BY UPPER(category) ORDER BY RANDOM()) as rnk
-									FROM assessment_questions 
-									WHERE assessment_level_id = ? 
-									  AND personality_trait_id = ?
-									  AND is_active = true 
-									  AND is_deleted = false
-									  AND UPPER(category) IN ('COMMITMENT', 'COURAGE', 'FOCUS', 'OPENNESS', 'RESPECT')
-								) t
-								WHERE rnk <= 5
-							`
-							args = []interface{}{
-								nextAttempt.ID, nextAttempt.AssessmentSessionID, nextAttempt.UserID, nextAttempt.RegistrationID, nextAttempt.ProgramID, nextLevel.ID,
-								nextLevel.ID, *traitID,
-							}
-						}
-
-						fmt.Printf("[SubmitAnswer] Generating Balanced Level 2 Questions for Attempt %d. Trait=%d, Board=%s\n", nextAttempt.ID, *traitID, studentBoard)
-						genResult := tx.Exec(query, args...)
-
-						if genResult.Error != nil {
-							fmt.Printf("[SubmitAnswer] Level 2 Generation ERROR for Attempt %d: %v\n", nextAttempt.ID, genResult.Error)
-						} else {
-							fmt.Printf("[SubmitAnswer] Level 2 Generation: %d questions generated for Attempt %d\n", genResult.RowsAffected, nextAttempt.ID)
-						}
-					}
-				}
-			}
-
-			// CASE B: No Next Level (System-wide) OR No Attempt for Next Level (Program-specific) -> Mark Completed
-			if !hasNextLevel {
-				sessionCompleted = true
-				completedUserID = lockedAttempt.UserID
-
-				// This session is FULLY COMPLETED
-				var session models.AssessmentSession
-				if err := tx.First(&session, answerRecord.AssessmentSessionID).Error; err == nil {
-					tx.Model(&session).Updates(map[string]interface{}{
-						"status":       "COMPLETED",
-						"completed_at": now,
-					})
-
-					// --- 🟢 GENERATE ASSESSMENT REPORT ---
-					var existingReport models.AssessmentReports
-					if err := tx.Where("assessment_session_id = ?", session.ID).First(&existingReport).Error; err != nil {
-						// Report does not exist, create it
-
-						// 1. Fetch Program Code
-						var program models.Program
-						tx.First(&program, session.ProgramID)
-
-						// 2. Fetch Group (if applicable)
-						groupIDStr := ""
-						if session.GroupID != nil {
-							groupIDStr = fmt.Sprintf("G%d-", *session.GroupID)
-						}
-
-						// 3. Generate Report Number Prefix
-						// Format: OBI-G{group_id}-{Month/Year}-{Program code}-
-						dateStr := now.Format("01/06") // Month/Year (e.g., 06/25)
-						shortProgramCode := program.Code
-						switch program.Code {
-						case "COLLEGE_STUDENT":
-							shortProgramCode = "CS"
-						case "SCHOOL_STUDENT":
-							shortProgramCode = "SS"
-						case "EMPLOYEE":
-							shortProgramCode = "E"
-						case "CXO_GENERAL":
-							shortProgramCode = "CG"
-						}
-						reportPrefix := fmt.Sprintf("OBI-%s%s-%s-", groupIDStr, dateStr, shortProgramCode)
-
-						// 4. Calculate Sequence Number
-						var count int64
-						tx.Model(&models.AssessmentReports{}).Where("report_number LIKE ?", reportPrefix+"%").Count(&count)
-						seqNum := count + 1
-						reportNumber := fmt.Sprintf("%s%03d", reportPrefix, seqNum)
-
-						// 5. Aggregate Data from Attempts
-						var attempts []models.AssessmentAttempt
-						tx.Where("assessment_session_id = ?", session.ID).Find(&attempts)
-
-						discScores := "{}"
-						agileScores := "{}"
-						level3Scores := "{}"
-						level4Scores := "{}"
-						var overallSincerity float64
-						var dominantTraitID *int64
-
-						// Loop attempts to extract scores based on level
-						for _, att := range attempts {
-							// Parse Level Info
-							var level models.AssessmentLevel
-							tx.First(&level, att.AssessmentLevelID)
-
-							// Attempt Metadata
-							var meta map[string]interface{}
-							if att.Metadata != "" && att.Metadata != "{}" {
-								json.Unmarshal([]byte(att.Metadata), &meta)
-							}
-
-							if level.LevelNumber == 1 || level.Name == "Level 1" || level.PatternType == "DISC" {
-								// DISC Scores
-								if val, ok := meta["disc_scores"]; ok {
-									bytes, _ := json.Marshal(val)
-									discScores = string(bytes)
-								}
-								overallSincerity = att.SincerityIndex
-								dominantTraitID = att.DominantTraitID
-							} else if level.LevelNumber == 2 || level.Name == "Level 2" {
-								// Agile Scores
-								if val, ok := meta["agile_scores"]; ok {
-									bytes, _ := json.Marshal(val)
-									agileScores = string(bytes)
-								}
-							}
-						}
-
-						fmt.Printf("DEBUG: Generating Report -> Prefix: %s, Number: %s\n", reportPrefix, reportNumber)
-
-						// Create Report Record
-						newReport := models.AssessmentReports{
-							AssessmentSessionID: session.ID,
-							ReportNumber:        reportNumber,
-							GeneratedAt:         now,
-							DiscScores:          discScores,
-							AgileScores:         agileScores,
-							Level3Scores:        level3Scores,
-							Level4Scores:        level4Scores,
-							OverallSincerity:    overallSincerity,
-							DominantTraitID:     dominantTraitID,
-							Metadata:            "{}",
-						}
-
-						// Save
-						if err := tx.Create(&newReport).Error; err != nil {
-							fmt.Printf("ERROR: Failed to create Assessment Report: %v\n", err)
-						} else {
-							fmt.Printf("SUCCESS: Assessment Report Created. ID: %d\n", newReport.ID)
-						}
-					} else {
-						// Report already exists - it was created when Level 1
-						// completed (so the Level 1 report could be downloaded
-						// early). Backfill the full score snapshot now that every
-						// level is done, keeping the original report number.
-						var attempts []models.AssessmentAttempt
-						tx.Where("assessment_session_id = ?", session.ID).Find(&attempts)
-
-						discScores := "{}"
-						agileScores := "{}"
-						var overallSincerity float64
-						var dominantTraitID *int64
-
-						for _, att := range attempts {
-							var level models.AssessmentLevel
-							tx.First(&level, att.AssessmentLevelID)
-
-							var meta map[string]interface{}
-							if att.Metadata != "" && att.Metadata != "{}" {
-								json.Unmarshal([]byte(att.Metadata), &meta)
-							}
-
-							if level.LevelNumber == 1 || level.Name == "Level 1" || level.PatternType == "DISC" {
-								if val, ok := meta["disc_scores"]; ok {
-									bytes, _ := json.Marshal(val)
-									discScores = string(bytes)
-								}
-								overallSincerity = att.SincerityIndex
-								dominantTraitID = att.DominantTraitID
-							} else if level.LevelNumber == 2 || level.Name == "Level 2" {
-								if val, ok := meta["agile_scores"]; ok {
-									bytes, _ := json.Marshal(val)
-									agileScores = string(bytes)
-								}
-							}
-						}
-
-						if err := tx.Model(&existingReport).Updates(map[string]interface{}{
-							"disc_scores":       discScores,
-							"agile_scores":      agileScores,
-							"overall_sincerity": overallSincerity,
-							"dominant_trait_id": dominantTraitID,
-						}).Error; err != nil {
-							fmt.Printf("ERROR: Failed to backfill Assessment Report %d: %v\n", existingReport.ID, err)
-						} else {
-							fmt.Printf("SUCCESS: Assessment Report %d backfilled (%s).\n", existingReport.ID, existingReport.ReportNumber)
-						}
-					}
-
-					// Update Group Assessment Status
-					if session.GroupID != nil {
-						var groupAssessment models.GroupAssessment
-						tx.Where("group_id = ? AND program_id = ?", *session.GroupID, session.ProgramID).First(&groupAssessment)
-
-						var stats struct {
-							Total     int64
-							Started   int64
-							Completed int64
-						}
-
-						// Count sessions in the group for this program
-						tx.Model(&models.AssessmentSession{}).
-							Where("group_id = ? AND program_id = ?", *session.GroupID, session.ProgramID).
-							Select("COUNT(*) as total, COUNT(*) FILTER (WHERE status != 'NOT_STARTED') as started, COUNT(*) FILTER (WHERE status = 'COMPLETED') as completed").
-							Scan(&stats)
-
-						newStatus := "NOT_STARTED"
-						isExpired := groupAssessment.ValidTo != nil && groupAssessment.ValidTo.Before(now)
-
-						if stats.Total > 0 {
-							if stats.Completed == stats.Total {
-								newStatus = "COMPLETED"
-							} else if isExpired {
-								if stats.Started > 0 {
-									// Some completed or started but unfinished AND time expired
-									newStatus = "PARTIALLY_EXPIRED"
-								} else {
-									// No one started AND time expired
-									newStatus = "EXPIRED"
-								}
-							} else {
-								// Not Expired
-								if stats.Started > 0 {
-									newStatus = "IN_PROGRESS"
-								}
-							}
-						}
-
-						// Update the GroupAssessment status
-						tx.Model(&models.GroupAssessment{}).
-							Where("group_id = ? AND program_id = ?", *session.GroupID, session.ProgramID).
-							Update("status", newStatus)
-					}
-				}
-			}
-
-			return nil
-		})
+	if totalCounts == 0 || answeredCounts != totalCounts {
+		return false, nil
+	}
 
-		if err != nil {
-			fmt.Printf("[SubmitAnswer] ERROR: Transaction Failed: %v\n", err)
-			return err
+	complete := s.completeAttempt
+	if s.complete != nil {
+		complete = s.complete
+	}
+	if _, err := complete(db, attemptID, completionAllAnswered); err != nil {
+		// The answer is saved; an attempt the scheduler expired meanwhile is
+		// simply not completed.
+		var examErr *ExamError
+		if errors.As(err, &examErr) && examErr.Code == ErrCodeNotInProgress {
+			return false, nil
 		}
+		fmt.Printf("[SubmitAnswer] ERROR: Completion Failed: %v\n", err)
+		return false, err
+	}
+	return true, nil
+}
 
-		if completedUserID > 0 {
-			go func(userID int64, isSessCompleted bool, nextLevelNum int) {
-				studentServiceURL := os.Getenv("STUDENT_SERVICE_URL")
-				if studentServiceURL == "" {
-					studentServiceURL = "http://localhost:4004"
-				}
-
-				if isSessCompleted {
-					endpoint := fmt.Sprintf("%s/student/assessment-complete", studentServiceURL)
-					payload := map[string]interface{}{"userId": userID}
-					jsonPayload, _ := json.Marshal(payload)
+// FinishAttempt completes an attempt on the candidate's request (skipped
+// questions, out of time) by scoring whatever was answered. It is subject to
+// the same access window and device session as answers, but not to
+// must_finish_by. Finishing an already-completed attempt is a no-op that
+// reports the stored outcome.
+func (s *ExamService) FinishAttempt(attemptID int64, caller Caller) (*models.AttemptCompletion, error) {
+	db := repository.GetDB()
 
-					fmt.Printf("[SubmitAnswer] Triggering student service for user %d at %s\n", userID, endpoint)
-					resp, err := http.Post(endpoint, "application/json", bytes.NewBuffer(jsonPayload))
-					if err != nil {
-						fmt.Printf("[SubmitAnswer] ERROR HTTP Post to student service: %v\n", err)
-					} else {
-						fmt.Printf("[SubmitAnswer] Triggered student service, status: %s\n", resp.Status)
-						resp.Body.Close()
-					}
-				} else if nextLevelNum > 0 {
-					endpoint := fmt.Sprintf("%s/student/assessment-level-unlocked", studentServiceURL)
-					payload := map[string]interface{}{
-						"userId":      userID,
-						"levelNumber": nextLevelNum,
-					}
-					jsonPayload, _ := json.Marshal(payload)
+	var attempt models.AssessmentAttempt
+	if err := db.Where("id = ? AND user_id = ?", attemptID, caller.UserID).First(&attempt).Error; err != nil {
+		return nil, &ExamError{Code: ErrCodeAttemptNotFound, Message: "assessment attempt not found or access denied"}
+	}
 
-					fmt.Printf("[SubmitAnswer] Triggering level unlock notification for user %d at %s\n", userID, endpoint)
-					resp, err := http.Post(endpoint, "application/json", bytes.NewBuffer(jsonPayload))
-					if err != nil {
-						fmt.Printf("[SubmitAnswer] ERROR HTTP Post to student service: %v\n", err)
-					} else {
-						fmt.Printf("[SubmitAnswer] Triggered level unlock notification, status: %s\n", resp.Status)
-						resp.Body.Close()
-					}
-				}
-			}(completedUserID, sessionCompleted, nextLevelNum)
+	if attempt.Status != "COMPLETED" {
+		if err := checkAttemptAccess(attempt, time.Now()); err != nil {
+			fmt.Printf("[FinishAttempt] REJECTED: Attempt %d: %v\n", attempt.ID, err)
+			return nil, err
+		}
+		if err := enforceDeviceLock(db, attempt, caller); err != nil {
+			fmt.Printf("[FinishAttempt] REJECTED: Attempt %d: %v\n", attempt.ID, err)
+			return nil, err
 		}
 	}
 
-	return nil
+	// Status and answered count are checked again under the row lock.
+	result, err := s.completeAttempt(db, attemptID, completionManual)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.AttemptCompletion{
+		AttemptID:        attemptID,
+		Status:           "COMPLETED",
+		AlreadyCompleted: result.AlreadyCompleted,
+		TotalQuestions:   result.TotalQuestions,
+		AnsweredCount:    result.AnsweredCount,
+		UnansweredCount:  result.TotalQuestions - result.AnsweredCount,
+		SessionCompleted: result.SessionCompleted,
+		NextLevelNumber:  result.NextLevelNumber,
+	}, nil
 }
 
 func (s *ExamService) isIATGenLevel2Attempt(db *gorm.DB, attempt models.AssessmentAttempt) bool {
@@ -0,0 +1,23 @@
+package service
+
+import (
+	"encoding/json"
+
+	"gorm.io/gorm"
+)
+
+// mergeAttemptMetadata shallow-merges `fields` into assessment_attempts.metadata
+// in a single statement, so concurrent writers touching different keys do not
+// overwrite each other's read-modify-write.
+func mergeAttemptMetadata(db *gorm.DB, attemptID int64, fields map[string]interface{}) error {
+	patch, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	return db.Exec(
+		`UPDATE assessment_attempts
+		 SET metadata = COALESCE(metadata, '{}'::jsonb) || ?::jsonb, updated_at = NOW()
+		 WHERE id = ?`,
+		string(patch), attemptID,
+	).Error
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// applyOpenSingleSelect records a single-option answer on an OPEN question.
+// A valid option earns the question's points (metadata "points", default 1);
+// the verdict is kept in the answer metadata under "correct".
+func applyOpenSingleSelect(tx *gorm.DB, answerRecord *models.AssessmentAnswer, answerMeta map[string]interface{}, question models.OpenQuestion, qMeta questionMeta, optionID int64) error {
+	var option models.OpenQuestionOption
+	if err := tx.Where("id = ? AND open_question_id = ? AND is_deleted = false", optionID, question.ID).First(&option).Error; err != nil {
+		return invalidAnswer(fmt.Sprintf("option %d does not belong to this question", optionID))
+	}
+
+	answerRecord.OpenOptionID = &optionID
+	answerRecord.AnswerScore = 0
+	if option.IsValid {
+		answerRecord.AnswerScore = fullPoints(qMeta)
+	}
+	answerMeta["correct"] = option.IsValid
+	return nil
+}
+
+// openTypeScore is the result for one OPEN question_type (or the "total"
+// roll-up) stored in the attempt metadata under "open_scores".
+type openTypeScore struct {
+	Questions int64   `json:"questions"`
+	Answered  int64   `json:"answered"`
+	Correct   int64   `json:"correct"`
+	Score     float64 `json:"score"`
+	// Accuracy is correct answers as a percentage of answered ones.
+	Accuracy float64 `json:"accuracy"`
+}
+
+// openTypeTotals is one question_type row of the OPEN aggregation.
+type openTypeTotals struct {
+	QuestionType string
+	Questions    int64
+	Answered     int64
+	Correct      int64
+	Score        float64
+}
+
+// loadOpenTypeTotals aggregates an attempt's OPEN answers per question_type.
+// Answers saved before correctness was recorded fall back to the chosen
+// option's is_valid, worth one point.
+func loadOpenTypeTotals(db *gorm.DB, attemptID int64) ([]openTypeTotals, error) {
+	var rows []openTypeTotals
+	err := db.Raw(`
+		SELECT UPPER(COALESCE(oq.question_type, '')) AS question_type,
+		       COUNT(*) AS questions,
+		       COUNT(*) FILTER (WHERE a.status = 'ANSWERED') AS answered,
+		       COUNT(*) FILTER (WHERE a.status = 'ANSWERED' AND COALESCE((a.metadata->>'correct')::boolean, o.is_valid, false)) AS correct,
+		       COALESCE(SUM(CASE
+		           WHEN a.status <> 'ANSWERED' THEN 0
+		           WHEN a.metadata->'correct' IS NOT NULL THEN a.answer_score
+		           WHEN o.is_valid THEN 1
+		           ELSE 0 END), 0) AS score
+		FROM assessment_answers a
+		JOIN open_questions oq ON oq.id = a.open_question_id
+		LEFT JOIN open_question_options o ON o.id = a.open_option_id
+		WHERE a.assessment_attempt_id = ? AND a.question_source = 'OPEN'
+		GROUP BY UPPER(COALESCE(oq.question_type, ''))
+	`, attemptID).Scan(&rows).Error
+	return rows, err
+}
+
+// summariseOpenScores turns per-type totals into per-type scores plus a
+// "total" roll-up. Returns nil when there are no OPEN questions.
+func summariseOpenScores(rows []openTypeTotals) map[string]openTypeScore {
+	if len(rows) == 0 {
+		return nil
+	}
+	summary := make(map[string]openTypeScore, len(rows)+1)
+	var total openTypeScore
+	for _, r := range rows {
+		key := strings.ToUpper(strings.TrimSpace(r.QuestionType))
+		if key == "" {
+			key = "OTHER"
+		}
+		item := summary[key]
+		item.Questions += r.Questions
+		item.Answered += r.Answered
+		item.Correct += r.Correct
+		item.Score += r.Score
+		summary[key] = item
+
+		total.Questions += r.Questions
+		total.Answered += r.Answered
+		total.Correct += r.Correct
+		total.Score += r.Score
+	}
+	summary["total"] = total
+	for key, item := range summary {
+		item.Score = roundHundredths(item.Score)
+		if item.Answered > 0 {
+			item.Accuracy = roundHundredths(float64(item.Correct) * 100 / float64(item.Answered))
+		}
+		summary[key] = item
+	}
+	return summary
+}
+
+// mergeOpenScores combines the open_scores of several attempts (a session's
+// levels) into one summary, recomputing accuracy.
+func mergeOpenScores(parts []map[string]openTypeScore) map[string]openTypeScore {
+	var rows []openTypeTotals
+	for _, part := range parts {
+		keys := make([]string, 0, len(part))
+		for key := range part {
+			if key != "total" {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			item := part[key]
+			rows = append(rows, openTypeTotals{
+				QuestionType: key,
+				Questions:    item.Questions,
+				Answered:     item.Answered,
+				Correct:      item.Correct,
+				Score:        item.Score,
+			})
+		}
+	}
+	return summariseOpenScores(rows)
+}
@@ -0,0 +1,49 @@
+package service
+
+import "testing"
+
+func TestSummariseOpenScores(t *testing.T) {
+	if got := summariseOpenScores(nil); got != nil {
+		t.Errorf("no OPEN questions should give nil, got %v", got)
+	}
+
+	got := summariseOpenScores([]openTypeTotals{
+		{QuestionType: "IMAGE", Questions: 5, Answered: 4, Correct: 3, Score: 3},
+		{QuestionType: "audio", Questions: 3, Answered: 3, Correct: 1, Score: 2},
+		{QuestionType: "VIDEO", Questions: 2},
+	})
+
+	want := map[string]openTypeScore{
+		"IMAGE": {Questions: 5, Answered: 4, Correct: 3, Score: 3, Accuracy: 75},
+		"AUDIO": {Questions: 3, Answered: 3, Correct: 1, Score: 2, Accuracy: 33.33},
+		"VIDEO": {Questions: 2},
+		"total": {Questions: 10, Answered: 7, Correct: 4, Score: 5, Accuracy: 57.14},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %+v, want %+v", k, got[k], v)
+		}
+	}
+}
+
+func TestMergeOpenScores(t *testing.T) {
+	level3 := summariseOpenScores([]openTypeTotals{{QuestionType: "IMAGE", Questions: 4, Answered: 4, Correct: 2, Score: 2}})
+	level4 := summariseOpenScores([]openTypeTotals{
+		{QuestionType: "IMAGE", Questions: 2, Answered: 2, Correct: 2, Score: 2},
+		{QuestionType: "DOCUMENT", Questions: 1, Answered: 1, Correct: 0},
+	})
+
+	got := mergeOpenScores([]map[string]openTypeScore{level3, level4})
+	if img := got["IMAGE"]; img.Answered != 6 || img.Correct != 4 || img.Accuracy != 66.67 {
+		t.Errorf("IMAGE = %+v", img)
+	}
+	if total := got["total"]; total.Questions != 7 || total.Correct != 4 || total.Accuracy != 57.14 {
+		t.Errorf("total = %+v", total)
+	}
+	if mergeOpenScores(nil) != nil {
+		t.Error("nothing to merge should give nil")
+	}
+}
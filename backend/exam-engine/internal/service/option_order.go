@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+// shuffledOptionsOrder returns the display_order positions of `positions` in
+// a per-candidate order. The shuffle is seeded from the attempt and the
+// question, so regenerating it for the same row always gives the same order.
+func shuffledOptionsOrder(attemptID int64, source string, questionID int64, positions []int) []int {
+	order := append([]int(nil), positions...)
+	sort.Ints(order)
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s:%d", attemptID, source, questionID)
+	rng := rand.New(rand.NewPCG(h.Sum64(), uint64(attemptID)))
+	rng.Shuffle(len(order), func(i, j int) { order[i], order[j] = order[j], order[i] })
+	return order
+}
+
+// answerOptionPositions lists the display_order of the active options of the
+// question an answer row points at (options must be preloaded).
+func answerOptionPositions(ans models.AssessmentAnswer) (int64, []int) {
+	var positions []int
+	if ans.MainQuestion != nil {
+		for _, opt := range ans.MainQuestion.Options {
+			if opt.IsActive && !opt.IsDeleted {
+				positions = append(positions, opt.DisplayOrder)
+			}
+		}
+		return ans.MainQuestion.ID, positions
+	}
+	if ans.OpenQuestion != nil {
+		for _, opt := range ans.OpenQuestion.Options {
+			if opt.IsActive && !opt.IsDeleted {
+				positions = append(positions, opt.DisplayOrder)
+			}
+		}
+		return ans.OpenQuestion.ID, positions
+	}
+	return 0, nil
+}
+
+// shuffleOptionsEnabled reports whether candidates see options in their
+// per-candidate order (assessment.shuffle_answer_options, on by default).
+// When it is off, stored orders are ignored and options are shown by
+// display_order.
+func shuffleOptionsEnabled(db *gorm.DB) bool {
+	return settingBool(db, "assessment", "shuffle_answer_options", true)
+}
+
+// fillOptionOrders persists the seeded question_options_order on every row
+// that has none yet and updates `answers` in place. It runs whenever the
+// paper is loaded, whatever the attempt status, and at Level 2 generation,
+// so an order is pinned the first time a row is seen and never reshuffled.
+// Rows that already carry an order are left as they are.
+func fillOptionOrders(db *gorm.DB, answers []models.AssessmentAnswer) {
+	for i := range answers {
+		ans := &answers[i]
+		if ans.QuestionOptionsOrder != "" {
+			continue
+		}
+		questionID, positions := answerOptionPositions(*ans)
+		if len(positions) < 2 {
+			continue
+		}
+		b, err := json.Marshal(shuffledOptionsOrder(ans.AssessmentAttemptID, ans.QuestionSource, questionID, positions))
+		if err != nil {
+			continue
+		}
+		if err := db.Model(&models.AssessmentAnswer{}).
+			Where("id = ? AND (question_options_order IS NULL OR question_options_order = '')", ans.ID).
+			Update("question_options_order", string(b)).Error; err != nil {
+			fmt.Printf("[OptionOrder] Failed to store order for Answer %d: %v\n", ans.ID, err)
+			continue
+		}
+		ans.QuestionOptionsOrder = string(b)
+	}
+}
+
+// assignOptionOrders pins the options of every freshly generated answer row
+// of an attempt.
+func assignOptionOrders(db *gorm.DB, attemptID int64) {
+	if !shuffleOptionsEnabled(db) {
+		return
+	}
+	answers, err := loadAttemptAnswers(db, attemptID)
+	if err != nil {
+		fmt.Printf("[OptionOrder] Failed to load answers for Attempt %d: %v\n", attemptID, err)
+		return
+	}
+	fillOptionOrders(db, answers)
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/repository"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// policySettingsTTL is how long a settings category is used before it is
+// read again, so policy edits apply without a restart.
+const policySettingsTTL = time.Minute
+
+// settingsRow is one originbi_settings row. Policies (sincerity, DISC
+// traits) are built from every row of their category.
+type settingsRow struct {
+	SettingKey   string
+	ValueType    string
+	ValueString  *string
+	ValueBoolean *bool
+	ValueNumber  *float64
+	ValueJSON    *string
+}
+
+type cachedCategory struct {
+	rows     []settingsRow
+	loadedAt time.Time
+}
+
+// settingsCategoryCache keeps whole settings categories for
+// policySettingsTTL. A failed reload keeps serving the previous rows.
+type settingsCategoryCache struct {
+	mu      sync.Mutex
+	entries map[string]*cachedCategory
+	load    func(category string) ([]settingsRow, error)
+	now     func() time.Time
+}
+
+func (c *settingsCategoryCache) rows(category string) []settingsRow {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	entry, ok := c.entries[category]
+	if ok && now.Sub(entry.loadedAt) < policySettingsTTL {
+		return entry.rows
+	}
+	rows, err := c.load(category)
+	if err != nil {
+		fmt.Printf("[Settings] Failed to load category %s: %v\n", category, err)
+		if !ok {
+			return nil
+		}
+		entry.loadedAt = now // retry after another TTL, not on every call
+		return entry.rows
+	}
+	if c.entries == nil {
+		c.entries = map[string]*cachedCategory{}
+	}
+	c.entries[category] = &cachedCategory{rows: rows, loadedAt: now}
+	return rows
+}
+
+// policySettings reads categories on their own connection, outside any
+// caller transaction.
+var policySettings = &settingsCategoryCache{
+	load: func(category string) ([]settingsRow, error) {
+		var rows []settingsRow
+		err := repository.GetDB().Raw(
+			`SELECT setting_key, value_type, value_string, value_boolean, value_number, value_json::text AS value_json
+			 FROM originbi_settings WHERE category = ?`, category,
+		).Scan(&rows).Error
+		return rows, err
+	},
+	now: time.Now,
+}
+
+// policyVersionRow returns the "policy_version" string of a category, or
+// def when it is unset.
+func policyVersionRow(rows []settingsRow, def string) string {
+	for _, row := range rows {
+		if row.SettingKey == "policy_version" && row.ValueString != nil && *row.ValueString != "" {
+			return *row.ValueString
+		}
+	}
+	return def
+}
+
+// programOverrideRows collects the json rows keyed "program_<id>".
+func programOverrideRows(rows []settingsRow) map[int64]json.RawMessage {
+	overrides := map[int64]json.RawMessage{}
+	for _, row := range rows {
+		if !strings.HasPrefix(row.SettingKey, "program_") || row.ValueJSON == nil {
+			continue
+		}
+		if id, err := strconv.ParseInt(strings.TrimPrefix(row.SettingKey, "program_"), 10, 64); err == nil {
+			overrides[id] = json.RawMessage(*row.ValueJSON)
+		}
+	}
+	return overrides
+}
+
+// applySettingField sets the field of policy (a pointer) whose json tag is
+// key. Values of the wrong type leave the field as it was.
+func applySettingField(policy interface{}, key string, value interface{}) {
+	b, _ := json.Marshal(map[string]interface{}{key: value})
+	if err := json.Unmarshal(b, policy); err != nil {
+		fmt.Printf("[Settings] Ignoring setting %s=%v: %v\n", key, value, err)
+	}
+}
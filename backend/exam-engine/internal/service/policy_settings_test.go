@@ -0,0 +1,27 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSettingsCategoryCacheKeepsCategoriesApart(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	cache := &settingsCategoryCache{
+		now: func() time.Time { return now },
+		load: func(category string) ([]settingsRow, error) {
+			if category == "disc" {
+				return nil, errors.New("db down")
+			}
+			return []settingsRow{sincerityNumberRow("attention_fail_penalty", 20)}, nil
+		},
+	}
+
+	if rows := cache.rows("sincerity"); len(rows) != 1 {
+		t.Fatalf("sincerity rows = %v", rows)
+	}
+	if rows := cache.rows("disc"); rows != nil {
+		t.Errorf("never-loaded category should give no rows, got %v", rows)
+	}
+}
@@ -0,0 +1,168 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"exam-engine/internal/repository"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Proctoring event types accepted from the client.
+const (
+	eventTabSwitch      = "TAB_SWITCH"
+	eventFullscreenExit = "FULLSCREEN_EXIT"
+	eventPaste          = "PASTE"
+	eventCopy           = "COPY"
+	eventFocusLost      = "FOCUS_LOST"
+)
+
+// proctoringPenalties is what each event costs the integrity score, and the
+// most a single event type may cost in total, so one noisy signal cannot
+// zero the score on its own.
+var proctoringPenalties = map[string]struct{ Each, Cap float64 }{
+	eventTabSwitch:      {Each: 5, Cap: 40},
+	eventFullscreenExit: {Each: 5, Cap: 30},
+	eventPaste:          {Each: 10, Cap: 50},
+	eventCopy:           {Each: 5, Cap: 25},
+	eventFocusLost:      {Each: 2, Cap: 20},
+}
+
+// integrityScore turns per-type event counts into a 0-100 score.
+func integrityScore(counters map[string]int64) float64 {
+	score := 100.0
+	for eventType, count := range counters {
+		penalty, ok := proctoringPenalties[eventType]
+		if !ok {
+			continue
+		}
+		cost := float64(count) * penalty.Each
+		if cost > penalty.Cap {
+			cost = penalty.Cap
+		}
+		score -= cost
+	}
+	if score < 0 {
+		return 0
+	}
+	return score
+}
+
+// RecordProctoringEvents stores a batch of client events for an in-progress
+// attempt and refreshes the counters and integrity score kept in the attempt
+// metadata under "proctoring". Events with an unknown type are rejected one
+// by one; a repeated client_event_id is counted as a duplicate. A caller
+// UserID of 0 skips the ownership check; the device lock applies as for
+// answers.
+func (s *ExamService) RecordProctoringEvents(attemptID int64, caller Caller, batch models.ProctoringEventBatch) (*models.ProctoringSummary, error) {
+	db := repository.GetDB()
+
+	var attempt models.AssessmentAttempt
+	if err := db.First(&attempt, attemptID).Error; err != nil || (caller.UserID != 0 && attempt.UserID != caller.UserID) {
+		return nil, &ExamError{Code: ErrCodeAttemptNotFound, Message: "assessment attempt not found"}
+	}
+	if attempt.Status != "IN_PROGRESS" {
+		return nil, &ExamError{
+			Code:    ErrCodeNotInProgress,
+			Message: "events can only be recorded while the attempt is in progress",
+			Details: map[string]interface{}{"status": attempt.Status},
+		}
+	}
+	// A device that lost the attempt must not add to its record.
+	if err := enforceDeviceLock(db, attempt, caller); err != nil {
+		fmt.Printf("[Proctoring] REJECTED: Attempt %d: %v\n", attemptID, err)
+		return nil, err
+	}
+
+	summary := &models.ProctoringSummary{AttemptID: attemptID}
+	now := time.Now()
+	rows := make([]models.ProctoringEvent, 0, len(batch.Events))
+	for _, in := range batch.Events {
+		eventType := strings.ToUpper(strings.TrimSpace(in.Type))
+		if _, ok := proctoringPenalties[eventType]; !ok {
+			summary.Rejected++
+			continue
+		}
+
+		row := models.ProctoringEvent{
+			AssessmentAttemptID: attemptID,
+			UserID:              attempt.UserID,
+			EventType:           eventType,
+			OccurredAt:          now,
+			DurationMs:          in.DurationMs,
+			Metadata:            "{}",
+		}
+		// Client clocks are not trusted beyond the attempt window.
+		if in.OccurredAt != nil && !in.OccurredAt.After(now) && (attempt.StartedAt == nil || !in.OccurredAt.Before(*attempt.StartedAt)) {
+			row.OccurredAt = *in.OccurredAt
+		}
+		if in.ClientEventID != "" {
+			id := in.ClientEventID
+			row.ClientEventID = &id
+		}
+		if len(in.Details) > 0 {
+			if b, err := json.Marshal(in.Details); err == nil {
+				row.Metadata = string(b)
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if len(rows) > 0 {
+			res := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows)
+			if res.Error != nil {
+				return res.Error
+			}
+			summary.Accepted = int(res.RowsAffected)
+			summary.Duplicates = len(rows) - summary.Accepted
+		}
+
+		counters, err := proctoringCounters(tx, attemptID)
+		if err != nil {
+			return err
+		}
+		summary.Counters = counters
+		summary.IntegrityScore = integrityScore(counters)
+
+		return mergeAttemptMetadata(tx, attemptID, map[string]interface{}{
+			"proctoring": map[string]interface{}{
+				"counters":        counters,
+				"integrity_score": summary.IntegrityScore,
+				"updated_at":      now,
+			},
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("[Proctoring] Attempt %d: accepted=%d duplicates=%d rejected=%d integrity=%.0f\n",
+		attemptID, summary.Accepted, summary.Duplicates, summary.Rejected, summary.IntegrityScore)
+	return summary, nil
+}
+
+// proctoringCounters counts the stored events of an attempt per type.
+func proctoringCounters(db *gorm.DB, attemptID int64) (map[string]int64, error) {
+	var rows []struct {
+		EventType string
+		Total     int64
+	}
+	if err := db.Model(&models.ProctoringEvent{}).
+		Select("event_type, COUNT(*) AS total").
+		Where("assessment_attempt_id = ?", attemptID).
+		Group("event_type").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counters := make(map[string]int64, len(rows))
+	for _, r := range rows {
+		counters[r.EventType] = r.Total
+	}
+	return counters, nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import "encoding/json"
+
+// questionMeta is the subset of assessment_questions.metadata /
+// open_questions.metadata the engine acts on. Unknown keys are ignored and
+// malformed metadata yields the zero value (single-select, default scoring).
+type questionMeta struct {
+	// MultiSelect lets the candidate pick several options.
+	MultiSelect bool `json:"multi_select"`
+	// ScoringRule for multi-select answers: sum, all_or_nothing or partial.
+	ScoringRule string `json:"scoring_rule"`
+	// MinSelections / MaxSelections bound a multi-select answer (0 = no bound).
+	MinSelections int `json:"min_selections"`
+	MaxSelections int `json:"max_selections"`
+
+	// ResponseType switches the question from choosing options to a typed
+	// response: text, number, essay or ranking. Empty means option choice.
+	ResponseType string   `json:"response_type"`
+	MaxLength    int      `json:"max_length"`
+	MinValue     *float64 `json:"min_value"`
+	MaxValue     *float64 `json:"max_value"`
+
+	// Answer key for typed responses. Points is the full credit (default 1).
+	Points          float64  `json:"points"`
+	AcceptedAnswers []string `json:"accepted_answers"`
+	AnswerPattern   string   `json:"answer_pattern"`
+	CaseSensitive   bool     `json:"case_sensitive"`
+	CorrectNumber   *float64 `json:"correct_number"`
+	Tolerance       float64  `json:"tolerance"`
+	CorrectRanking  []int64  `json:"correct_ranking"`
+
+	// ScoreCategories lists the categories a Level 3/4 question's score
+	// counts towards, overriding its category (or OPEN question_type).
+	ScoreCategories []string `json:"score_categories"`
+}
+
+func parseQuestionMeta(raw string) questionMeta {
+	var meta questionMeta
+	if raw == "" || raw == "{}" {
+		return meta
+	}
+	if err := json.Unmarshal([]byte(raw), &meta); err != nil {
+		return questionMeta{}
+	}
+	return meta
+}
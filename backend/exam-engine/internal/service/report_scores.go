@@ -0,0 +1,110 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// reportScores is the score snapshot copied from a session's attempts onto
+// its assessment_reports row. Score fields are JSON, "{}" when the level has
+// not been scored.
+type reportScores struct {
+	// Disc also carries the DISC graph profile under "profile".
+	Disc             string
+	Agile            string
+	Level3           string
+	Level4           string
+	OverallSincerity float64
+	DominantTraitID  *int64
+	// Metadata is the report metadata JSON: "open_scores" merges the OPEN
+	// question results of every level.
+	Metadata string
+}
+
+// collectReportScores gathers the snapshot for a session from its attempts.
+func collectReportScores(tx *gorm.DB, sessionID int64) reportScores {
+	var attempts []models.AssessmentAttempt
+	tx.Where("assessment_session_id = ?", sessionID).Find(&attempts)
+
+	levels := make(map[int]models.AssessmentLevel)
+	for _, att := range attempts {
+		if att.AssessmentLevelID == nil {
+			continue
+		}
+		if _, ok := levels[*att.AssessmentLevelID]; !ok {
+			var level models.AssessmentLevel
+			tx.First(&level, *att.AssessmentLevelID)
+			levels[*att.AssessmentLevelID] = level
+		}
+	}
+	return buildReportScores(attempts, levels)
+}
+
+// buildReportScores copies each level's scores from the attempt metadata
+// (where the level's Scorer stored them) and takes the sincerity and
+// dominant trait from the DISC attempt.
+func buildReportScores(attempts []models.AssessmentAttempt, levels map[int]models.AssessmentLevel) reportScores {
+	scores := reportScores{Disc: "{}", Agile: "{}", Level3: "{}", Level4: "{}", Metadata: "{}"}
+	fields := map[string]*string{
+		"disc_scores":   &scores.Disc,
+		"agile_scores":  &scores.Agile,
+		"level3_scores": &scores.Level3,
+		"level4_scores": &scores.Level4,
+	}
+
+	var openParts []map[string]openTypeScore
+	for _, att := range attempts {
+		var level models.AssessmentLevel
+		if att.AssessmentLevelID != nil {
+			level = levels[*att.AssessmentLevelID]
+		}
+
+		var meta map[string]json.RawMessage
+		if att.Metadata != "" && att.Metadata != "{}" {
+			json.Unmarshal([]byte(att.Metadata), &meta)
+		}
+		for key, field := range fields {
+			if val, ok := meta[key]; ok && string(val) != "null" {
+				*field = string(val)
+			}
+		}
+		if val, ok := meta["disc_profile"]; ok && string(val) != "null" {
+			scores.Disc = withDiscProfile(scores.Disc, val)
+		}
+		if val, ok := meta["open_scores"]; ok {
+			var part map[string]openTypeScore
+			if json.Unmarshal(val, &part) == nil && len(part) > 0 {
+				openParts = append(openParts, part)
+			}
+		}
+
+		if levelPatternType(level) == "DISC" {
+			scores.OverallSincerity = att.SincerityIndex
+			scores.DominantTraitID = att.DominantTraitID
+		}
+	}
+
+	if open := mergeOpenScores(openParts); open != nil {
+		if b, err := json.Marshal(map[string]interface{}{"open_scores": open}); err == nil {
+			scores.Metadata = string(b)
+		}
+	}
+	return scores
+}
+
+// withDiscProfile adds the profile to a disc_scores JSON object, leaving
+// it unchanged when it does not parse.
+func withDiscProfile(disc string, profile json.RawMessage) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(disc), &fields); err != nil || fields == nil {
+		return disc
+	}
+	fields["profile"] = profile
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return disc
+	}
+	return string(b)
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"testing"
+)
+
+func TestBuildReportScores(t *testing.T) {
+	l1, l2, l3, l4 := 1, 2, 3, 4
+	trait := int64(7)
+	levels := map[int]models.AssessmentLevel{
+		l1: {ID: l1, LevelNumber: 1, PatternType: "DISC"},
+		l2: {ID: l2, LevelNumber: 2, PatternType: "ACI"},
+		l3: {ID: l3, LevelNumber: 3, PatternType: "IAT_GEN"},
+		l4: {ID: l4, LevelNumber: 4, PatternType: "METAPHOR"},
+	}
+	attempts := []models.AssessmentAttempt{
+		{AssessmentLevelID: &l1, SincerityIndex: 85, DominantTraitID: &trait, Metadata: `{"disc_scores": {"D": 10, "total": 10}}`},
+		{AssessmentLevelID: &l2, SincerityIndex: 40, Metadata: "{}"},
+		{AssessmentLevelID: &l3, SincerityIndex: 60, Metadata: `{"level3_scores": {"LOGIC": 4, "total": 4}, "open_scores": {"IMAGE": {"questions": 2, "answered": 2, "correct": 1, "score": 1}}}`},
+		{AssessmentLevelID: &l4, Metadata: `{"level4_scores": null}`},
+	}
+
+	got := buildReportScores(attempts, levels)
+	if got.Disc != `{"D": 10, "total": 10}` {
+		t.Errorf("disc = %s", got.Disc)
+	}
+	if got.Level3 != `{"LOGIC": 4, "total": 4}` {
+		t.Errorf("level3 = %s", got.Level3)
+	}
+	if got.Agile != "{}" || got.Level4 != "{}" {
+		t.Errorf("unscored levels should stay {}: agile %s, level4 %s", got.Agile, got.Level4)
+	}
+	if got.Metadata != `{"open_scores":{"IMAGE":{"questions":2,"answered":2,"correct":1,"score":1,"accuracy":50},"total":{"questions":2,"answered":2,"correct":1,"score":1,"accuracy":50}}}` {
+		t.Errorf("metadata = %s", got.Metadata)
+	}
+	if got.OverallSincerity != 85 || got.DominantTraitID == nil || *got.DominantTraitID != 7 {
+		t.Errorf("sincerity/trait should come from the DISC attempt: %v %v", got.OverallSincerity, got.DominantTraitID)
+	}
+}
+
+func TestBuildReportScoresDiscProfile(t *testing.T) {
+	l1 := 1
+	levels := map[int]models.AssessmentLevel{l1: {ID: l1, LevelNumber: 1, PatternType: "DISC"}}
+	attempts := []models.AssessmentAttempt{
+		{AssessmentLevelID: &l1, Metadata: `{"disc_scores": {"D": 10, "total": 10}, "disc_profile": {"code": "D", "pure": true}}`},
+	}
+
+	got := buildReportScores(attempts, levels)
+	if got.Disc != `{"D":10,"profile":{"code":"D","pure":true},"total":10}` {
+		t.Errorf("disc = %s", got.Disc)
+	}
+}
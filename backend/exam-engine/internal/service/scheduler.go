@@ -1,9 +1,13 @@
 package service
 
 import (
+	"errors"
 	"exam-engine/internal/models"
 	"exam-engine/internal/repository"
+	"fmt"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // StartScheduler initializes the background job ticker
@@ -35,7 +39,13 @@ func ExpireAttempts() {
 		Where("status = ? AND expires_at < ?", "NOT_STARTED", now).
 		Update("status", "EXPIRED")
 
-	// 2. Mark IN_PROGRESS attempts as PARTIALLY_EXPIRED if past expires_at
+	// 2. Optionally score timed-out IN_PROGRESS attempts that were answered
+	//    far enough (assessment.auto_submit_expired_attempts setting).
+	if policy := loadAutoSubmitPolicy(db); policy.Enabled {
+		autoSubmitTimedOutAttempts(db, now, policy)
+	}
+
+	// 3. Mark IN_PROGRESS attempts as PARTIALLY_EXPIRED if past expires_at
 	//    Ideally, if an exam is strictly timed, the system should submit it.
 	//    But as a fail-safe, we mark it partially expired or completed depending on business logic.
 	//    The requirement says: "if exam is in progress and date is expired => partially_expired"
@@ -44,6 +54,123 @@ func ExpireAttempts() {
 		Update("status", "PARTIALLY_EXPIRED")
 }
 
+// Auto-submit decisions recorded under "auto_submit" in the attempt metadata.
+const (
+	autoSubmitScored  = "SCORED"
+	autoSubmitExpired = "EXPIRED"
+	autoSubmitWait    = "" // left for the candidate to finish
+)
+
+// errAttemptSettled rolls back an auto-submit whose attempt was completed or
+// expired by someone else since it was selected.
+var errAttemptSettled = errors.New("attempt no longer in progress")
+
+// autoSubmitPolicy is the assessment.auto_submit_expired_attempts and
+// assessment.auto_submit_min_coverage (percent) settings.
+type autoSubmitPolicy struct {
+	Enabled     bool
+	MinCoverage float64
+}
+
+func loadAutoSubmitPolicy(db *gorm.DB) autoSubmitPolicy {
+	return autoSubmitPolicy{
+		Enabled:     settingBool(db, "assessment", "auto_submit_expired_attempts", false),
+		MinCoverage: settingNumber(db, "assessment", "auto_submit_min_coverage", 80),
+	}
+}
+
+// decide says what happens to a timed-out IN_PROGRESS attempt with answered
+// of total questions: scored when auto-submit is enabled and the coverage
+// exceeds MinCoverage, otherwise marked PARTIALLY_EXPIRED once past
+// expires_at. It also returns the coverage.
+func (p autoSubmitPolicy) decide(total, answered int64, pastExpiry bool) (string, float64) {
+	var coverage float64
+	if total > 0 {
+		coverage = float64(answered) * 100 / float64(total)
+	}
+	switch {
+	case p.Enabled && answered > 0 && coverage > p.MinCoverage:
+		return autoSubmitScored, coverage
+	case pastExpiry:
+		return autoSubmitExpired, coverage
+	default:
+		return autoSubmitWait, coverage
+	}
+}
+
+// autoSubmitTimedOutAttempts scores IN_PROGRESS attempts whose time is up
+// (must_finish_by or expires_at passed) through the normal completion
+// pipeline when the answered coverage exceeds the policy's MinCoverage.
+// Attempts at or below the threshold that are past expires_at are marked
+// PARTIALLY_EXPIRED here so the decision can be recorded; the rest are left for
+// the candidate to finish. The decision and coverage go into the attempt
+// metadata under "auto_submit", in the same transaction as the status change,
+// so no attempt is completed or expired without its decision.
+func autoSubmitTimedOutAttempts(db *gorm.DB, now time.Time, policy autoSubmitPolicy) {
+	var attempts []models.AssessmentAttempt
+	db.Where("status = ? AND (expires_at < ? OR must_finish_by < ?)", "IN_PROGRESS", now, now.Add(-answerGracePeriod)).
+		Find(&attempts)
+
+	svc := NewExamService()
+	for _, attempt := range attempts {
+		var stats struct {
+			Total    int64
+			Answered int64
+		}
+		db.Model(&models.AssessmentAnswer{}).
+			Where("assessment_attempt_id = ?", attempt.ID).
+			Select("COUNT(*) as total, COUNT(*) FILTER (WHERE status = 'ANSWERED') as answered").
+			Scan(&stats)
+
+		pastExpiry := attempt.ExpiresAt != nil && attempt.ExpiresAt.Before(now)
+		outcome, coverage := policy.decide(stats.Total, stats.Answered, pastExpiry)
+
+		if outcome == autoSubmitWait {
+			continue
+		}
+		decision := map[string]interface{}{
+			"decision":       outcome,
+			"coverage":       coverage,
+			"min_coverage":   policy.MinCoverage,
+			"answered_count": stats.Answered,
+			"total":          stats.Total,
+			"evaluated_at":   now,
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			switch outcome {
+			case autoSubmitScored:
+				result, err := svc.completeAttempt(tx, attempt.ID, completionAutoSubmit)
+				if err != nil {
+					return err
+				}
+				if result.AlreadyCompleted {
+					return errAttemptSettled
+				}
+			case autoSubmitExpired:
+				res := tx.Model(&models.AssessmentAttempt{}).
+					Where("id = ? AND status = ?", attempt.ID, "IN_PROGRESS").
+					Update("status", "PARTIALLY_EXPIRED")
+				if res.Error != nil {
+					return res.Error
+				}
+				if res.RowsAffected == 0 {
+					return errAttemptSettled
+				}
+			}
+			return mergeAttemptMetadata(tx, attempt.ID, map[string]interface{}{"auto_submit": decision})
+		})
+		switch {
+		case errors.Is(err, errAttemptSettled):
+			continue
+		case err != nil:
+			fmt.Printf("[Scheduler] Auto-submit (%s) failed for Attempt %d: %v\n", outcome, attempt.ID, err)
+		case outcome == autoSubmitScored:
+			fmt.Printf("[Scheduler] Auto-submitted Attempt %d at %.1f%% coverage\n", attempt.ID, coverage)
+		}
+	}
+}
+
 // ExpireSessions updates the status of sessions based on valid_to
 func ExpireSessions() {
 	db := repository.GetDB()
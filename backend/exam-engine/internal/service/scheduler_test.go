@@ -0,0 +1,38 @@
+package service
+
+import "testing"
+
+func TestAutoSubmitDecide(t *testing.T) {
+	on := autoSubmitPolicy{Enabled: true, MinCoverage: 80}
+	off := autoSubmitPolicy{MinCoverage: 80}
+
+	cases := []struct {
+		name            string
+		policy          autoSubmitPolicy
+		total, answered int64
+		pastExpiry      bool
+		want            string
+		wantCoverage    float64
+	}{
+		{"at the threshold", on, 10, 8, false, autoSubmitWait, 80},
+		{"at the threshold, past expiry", on, 10, 8, true, autoSubmitExpired, 80},
+		{"just above", on, 1000, 801, false, autoSubmitScored, 80.1},
+		{"just below, time up", on, 100, 79, false, autoSubmitWait, 79},
+		{"just below, past expiry", on, 100, 79, true, autoSubmitExpired, 79},
+		{"above, past expiry", on, 10, 9, true, autoSubmitScored, 90},
+		{"zero threshold still needs an answer", autoSubmitPolicy{Enabled: true}, 10, 0, true, autoSubmitExpired, 0},
+		{"zero threshold, one answer", autoSubmitPolicy{Enabled: true}, 10, 1, false, autoSubmitScored, 10},
+		{"no questions", on, 0, 0, false, autoSubmitWait, 0},
+		{"no questions, past expiry", on, 0, 0, true, autoSubmitExpired, 0},
+		{"setting off, full coverage", off, 10, 10, false, autoSubmitWait, 100},
+		{"setting off, past expiry", off, 10, 10, true, autoSubmitExpired, 100},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, coverage := c.policy.decide(c.total, c.answered, c.pastExpiry)
+			if got != c.want || coverage != c.wantCoverage {
+				t.Errorf("got (%q, %v), want (%q, %v)", got, coverage, c.want, c.wantCoverage)
+			}
+		})
+	}
+}
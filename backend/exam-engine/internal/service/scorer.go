@@ -0,0 +1,101 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gorm.io/gorm"
+)
+
+// ScoreResult is what every Scorer produces for a completed attempt.
+type ScoreResult struct {
+	// Scores maps each dimension (DISC factor, agile category, ...) to its
+	// score. The completion path adds "total" before storing it.
+	Scores map[string]float64
+	Total  float64
+	// DominantCode is the personality_traits code the attempt resolves to,
+	// or "" when the pattern has none.
+	DominantCode string
+	// Extras are stored in the attempt metadata beside the scores.
+	Extras map[string]interface{}
+}
+
+// Scorer scores the answers of one assessment pattern.
+type Scorer interface {
+	// MetadataKey is the attempt metadata key the scores are stored under
+	// (e.g. "disc_scores").
+	MetadataKey() string
+	Score(tx *gorm.DB, attempt models.AssessmentAttempt, level models.AssessmentLevel) (*ScoreResult, error)
+}
+
+var (
+	scorersMu sync.RWMutex
+	scorers   = map[string]Scorer{}
+)
+
+// RegisterScorer makes a Scorer available for a pattern type (matched case
+// insensitively against assessment_levels.pattern_type). Registering the
+// same pattern twice is a programming error and panics.
+func RegisterScorer(patternType string, scorer Scorer) {
+	key := strings.ToUpper(strings.TrimSpace(patternType))
+	scorersMu.Lock()
+	defer scorersMu.Unlock()
+	if scorer == nil {
+		panic("service: RegisterScorer scorer is nil")
+	}
+	if _, dup := scorers[key]; dup {
+		panic("service: RegisterScorer called twice for pattern " + key)
+	}
+	scorers[key] = scorer
+}
+
+// RegisterBuiltinScorers registers the scorers shipped with the engine. It
+// is called once at startup.
+func RegisterBuiltinScorers() {
+	RegisterScorer("DISC", discScorer{})
+	RegisterScorer("AGILE", agileScorer{})
+	RegisterScorer("ACI", agileScorer{}) // Level 2 rows use the ACI name
+	RegisterScorer("IAT_GEN", categoryScorer{metadataKey: "level3_scores"})
+	RegisterScorer("METAPHOR", categoryScorer{metadataKey: "level4_scores"})
+}
+
+// levelPatternType is the pattern a level is scored as. Rows seeded before
+// pattern_type was filled in fall back to their level number.
+func levelPatternType(level models.AssessmentLevel) string {
+	if p := strings.ToUpper(strings.TrimSpace(level.PatternType)); p != "" {
+		return p
+	}
+	switch {
+	case level.LevelNumber == 1 || level.Name == "Level 1":
+		return "DISC"
+	case level.LevelNumber == 2 || level.Name == "Level 2":
+		return "ACI"
+	case level.LevelNumber == 3:
+		return "IAT_GEN"
+	case level.LevelNumber == 4:
+		return "METAPHOR"
+	}
+	return ""
+}
+
+// scorerFor returns the Scorer registered for a level, or an error naming
+// the level when none is.
+func scorerFor(level models.AssessmentLevel) (Scorer, error) {
+	pattern := levelPatternType(level)
+	scorersMu.RLock()
+	scorer, ok := scorers[pattern]
+	registered := make([]string, 0, len(scorers))
+	for k := range scorers {
+		registered = append(registered, k)
+	}
+	scorersMu.RUnlock()
+	if !ok {
+		sort.Strings(registered)
+		return nil, fmt.Errorf("no scorer registered for pattern %q (level %d %q); registered: %s",
+			pattern, level.LevelNumber, level.Name, strings.Join(registered, ", "))
+	}
+	return scorer, nil
+}
@@ -0,0 +1,54 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// agileCategories are the Level 2 (ACI) dimensions. They are always
+// reported, zero when unanswered; other categories only count towards the
+// total.
+var agileCategories = map[string]string{
+	"COMMITMENT": "Commitment",
+	"COURAGE":    "Courage",
+	"FOCUS":      "Focus",
+	"OPENNESS":   "Openness",
+	"RESPECT":    "Respect",
+}
+
+// agileScorer scores Level 2 (ACI): answer scores summed per question
+// category.
+type agileScorer struct{}
+
+func (agileScorer) MetadataKey() string { return "agile_scores" }
+
+func (agileScorer) Score(tx *gorm.DB, attempt models.AssessmentAttempt, level models.AssessmentLevel) (*ScoreResult, error) {
+	type categoryTotal struct {
+		Category string
+		Total    float64
+	}
+	var totals []categoryTotal
+	err := tx.Raw(`
+		SELECT UPPER(q.category) as category, SUM(a.answer_score) as total
+		FROM assessment_answers a
+		JOIN assessment_questions q ON a.main_question_id = q.id
+		WHERE a.assessment_attempt_id = ?
+		GROUP BY UPPER(q.category)
+	`, attempt.ID).Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScoreResult{Scores: map[string]float64{}}
+	for _, name := range agileCategories {
+		result.Scores[name] = 0
+	}
+	for _, t := range totals {
+		result.Total += t.Total
+		if name, ok := agileCategories[t.Category]; ok {
+			result.Scores[name] = t.Total
+		}
+	}
+	return result, nil
+}
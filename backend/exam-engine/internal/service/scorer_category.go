@@ -0,0 +1,66 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// categoryScorer scores the higher levels: answer scores summed per
+// category. A question counts towards the categories in its metadata
+// "score_categories", or else its own category (question_type for OPEN
+// questions). The total counts each answer once.
+type categoryScorer struct {
+	metadataKey string
+}
+
+func (s categoryScorer) MetadataKey() string { return s.metadataKey }
+
+// categoryAnswer is one answered question as the category scorer sees it.
+type categoryAnswer struct {
+	AnswerScore float64
+	Category    string
+	Metadata    string
+}
+
+func (categoryScorer) Score(tx *gorm.DB, attempt models.AssessmentAttempt, level models.AssessmentLevel) (*ScoreResult, error) {
+	var answers []categoryAnswer
+	err := tx.Raw(`
+		SELECT a.answer_score,
+		       COALESCE(q.category, oq.question_type, '') AS category,
+		       COALESCE(q.metadata, oq.metadata, '{}'::jsonb)::text AS metadata
+		FROM assessment_answers a
+		LEFT JOIN assessment_questions q ON q.id = a.main_question_id
+		LEFT JOIN open_questions oq ON oq.id = a.open_question_id
+		WHERE a.assessment_attempt_id = ? AND a.status = 'ANSWERED'
+	`, attempt.ID).Scan(&answers).Error
+	if err != nil {
+		return nil, err
+	}
+	return aggregateCategoryScores(answers), nil
+}
+
+// aggregateCategoryScores sums answer scores per upper-cased category.
+// Answers without any category only count towards the total.
+func aggregateCategoryScores(answers []categoryAnswer) *ScoreResult {
+	result := &ScoreResult{Scores: map[string]float64{}}
+	for _, a := range answers {
+		result.Total += a.AnswerScore
+
+		categories := parseQuestionMeta(a.Metadata).ScoreCategories
+		if len(categories) == 0 {
+			categories = []string{a.Category}
+		}
+		seen := map[string]bool{}
+		for _, c := range categories {
+			c = strings.ToUpper(strings.TrimSpace(c))
+			if c == "" || seen[c] {
+				continue
+			}
+			seen[c] = true
+			result.Scores[c] += a.AnswerScore
+		}
+	}
+	return result
+}
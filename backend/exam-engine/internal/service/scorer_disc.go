@@ -0,0 +1,68 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// discScorer scores Level 1 (DISC): option-based factor sums, with the
+// dominant factor resolved pure-trait aware (see disc_trait.go), plus the
+// graph profile (see disc_profile.go).
+type discScorer struct{}
+
+func (discScorer) MetadataKey() string { return "disc_scores" }
+
+func (discScorer) Score(tx *gorm.DB, attempt models.AssessmentAttempt, level models.AssessmentLevel) (*ScoreResult, error) {
+	type factorTotal struct {
+		DiscFactor string
+		Total      float64
+	}
+	var totals []factorTotal
+	// Multi-select answers contribute every picked option, not just
+	// the first one kept in main_option_id.
+	err := tx.Raw(`
+		SELECT o.disc_factor, SUM(o.score_value) as total 
+		FROM assessment_answers a 
+		JOIN assessment_question_options o ON a.main_option_id = o.id 
+			OR (a.is_multiple_selection AND o.id IN (
+				SELECT jsonb_array_elements_text(COALESCE(a.metadata->'selected_option_ids', '[]'::jsonb))::bigint))
+		WHERE a.assessment_attempt_id = ? 
+		GROUP BY o.disc_factor
+	`, attempt.ID).Scan(&totals).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ScoreResult{Scores: map[string]float64{}}
+	for _, t := range totals {
+		result.Total += t.Total
+		if t.DiscFactor != "" {
+			result.Scores[t.DiscFactor] = t.Total
+		}
+	}
+	// The pure-trait rules may differ per program; record which ones were
+	// used and which rule decided, so reports can explain the code.
+	policy := loadDiscTraitPolicy(attempt.ProgramID)
+	code, rule := policy.resolve(result.Scores)
+	result.DominantCode = code
+	result.Extras = map[string]interface{}{
+		"disc_policy_version": policy.Version,
+		"disc_policy":         policy,
+		"disc_trait_rule":     rule,
+	}
+
+	// Percentages, bands and graph segments, so report renderers stop
+	// deriving their own from the raw sums.
+	var label string
+	if code != "" {
+		var trait models.PersonalityTrait
+		if err := tx.Where("code = ?", code).First(&trait).Error; err == nil {
+			label = trait.BlendedStyleName
+		}
+	}
+	if profile := buildDiscProfile(result.Scores, code, label); profile != nil {
+		result.Extras["disc_profile"] = profile
+	}
+	return result, nil
+}
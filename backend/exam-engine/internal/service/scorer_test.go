@@ -0,0 +1,101 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"strings"
+	"testing"
+)
+
+// withScorers runs fn against an empty registry and restores the old one.
+func withScorers(t *testing.T, fn func()) {
+	t.Helper()
+	scorersMu.Lock()
+	saved := scorers
+	scorers = map[string]Scorer{}
+	scorersMu.Unlock()
+	defer func() {
+		scorersMu.Lock()
+		scorers = saved
+		scorersMu.Unlock()
+	}()
+	fn()
+}
+
+func TestLevelPatternType(t *testing.T) {
+	cases := []struct {
+		level models.AssessmentLevel
+		want  string
+	}{
+		{models.AssessmentLevel{LevelNumber: 1, PatternType: "disc"}, "DISC"},
+		{models.AssessmentLevel{LevelNumber: 1}, "DISC"},
+		{models.AssessmentLevel{Name: "Level 2"}, "ACI"},
+		{models.AssessmentLevel{LevelNumber: 2, PatternType: " ACI "}, "ACI"},
+		{models.AssessmentLevel{LevelNumber: 3, PatternType: "IAT_GEN"}, "IAT_GEN"},
+		{models.AssessmentLevel{LevelNumber: 4}, "METAPHOR"},
+		{models.AssessmentLevel{LevelNumber: 5}, ""},
+	}
+	for _, c := range cases {
+		if got := levelPatternType(c.level); got != c.want {
+			t.Errorf("levelPatternType(%+v) = %q, want %q", c.level, got, c.want)
+		}
+	}
+}
+
+func TestScorerRegistry(t *testing.T) {
+	withScorers(t, func() {
+		RegisterBuiltinScorers()
+
+		scorer, err := scorerFor(models.AssessmentLevel{LevelNumber: 1, PatternType: "DISC"})
+		if err != nil || scorer.MetadataKey() != "disc_scores" {
+			t.Fatalf("DISC: scorer %v, err %v", scorer, err)
+		}
+		scorer, err = scorerFor(models.AssessmentLevel{LevelNumber: 2, PatternType: "ACI"})
+		if err != nil || scorer.MetadataKey() != "agile_scores" {
+			t.Fatalf("ACI: scorer %v, err %v", scorer, err)
+		}
+
+		scorer, err = scorerFor(models.AssessmentLevel{LevelNumber: 4})
+		if err != nil || scorer.MetadataKey() != "level4_scores" {
+			t.Fatalf("Level 4: scorer %v, err %v", scorer, err)
+		}
+
+		_, err = scorerFor(models.AssessmentLevel{LevelNumber: 5, Name: "Future", PatternType: "TBD"})
+		if err == nil || !strings.Contains(err.Error(), `"TBD"`) {
+			t.Errorf("unknown pattern: err = %v", err)
+		}
+		// A known level number does not rescue an unknown pattern.
+		if _, err = scorerFor(models.AssessmentLevel{LevelNumber: 1, PatternType: "PERSONALITY"}); err == nil {
+			t.Error("Level 1 typed PERSONALITY was scored")
+		}
+
+		defer func() {
+			if recover() == nil {
+				t.Error("registering a pattern twice should panic")
+			}
+		}()
+		RegisterScorer("disc", discScorer{})
+	})
+}
+
+func TestAggregateCategoryScores(t *testing.T) {
+	result := aggregateCategoryScores([]categoryAnswer{
+		{AnswerScore: 3, Category: "Logic"},
+		{AnswerScore: 2, Category: "logic "},
+		{AnswerScore: 4, Category: "Memory", Metadata: `{"score_categories": ["Memory", "Attention", "memory"]}`},
+		{AnswerScore: 1, Category: "VIDEO", Metadata: "{}"},
+		{AnswerScore: 5},
+	})
+
+	want := map[string]float64{"LOGIC": 5, "MEMORY": 4, "ATTENTION": 4, "VIDEO": 1}
+	if len(result.Scores) != len(want) {
+		t.Fatalf("scores = %v, want %v", result.Scores, want)
+	}
+	for k, v := range want {
+		if result.Scores[k] != v {
+			t.Errorf("scores[%s] = %v, want %v", k, result.Scores[k], v)
+		}
+	}
+	if result.Total != 15 {
+		t.Errorf("total = %v, want 15 (each answer once)", result.Total)
+	}
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"database/sql"
+
+	"gorm.io/gorm"
+)
+
+// settingBool reads a boolean originbi_settings value, returning def when the
+// row is missing, NULL or unreadable.
+func settingBool(db *gorm.DB, category string, key string, def bool) bool {
+	var value sql.NullBool
+	err := db.Raw(
+		`SELECT value_boolean FROM originbi_settings WHERE category = ? AND setting_key = ? LIMIT 1`,
+		category, key,
+	).Row().Scan(&value)
+	if err != nil || !value.Valid {
+		return def
+	}
+	return value.Bool
+}
+
+// settingNumber reads a numeric originbi_settings value, returning def when
+// the row is missing, NULL or unreadable.
+func settingNumber(db *gorm.DB, category string, key string, def float64) float64 {
+	var value sql.NullFloat64
+	err := db.Raw(
+		`SELECT value_number FROM originbi_settings WHERE category = ? AND setting_key = ? LIMIT 1`,
+		category, key,
+	).Row().Scan(&value)
+	if err != nil || !value.Valid {
+		return def
+	}
+	return value.Float64
+}
@@ -0,0 +1,39 @@
+package service
+
+// sincerityInputs are the signals the sincerity index is built from.
+type sincerityInputs struct {
+	AttentionFails     int64
+	DistractionsChosen int64
+	// IntegrityScore is the proctoring score (0-100); nil when the client
+	// sent no proctoring events.
+	IntegrityScore *float64
+	// AnomalyPenalty is the total of the response-pattern signals
+	// (speeding, straight-lining, flipping); see detectAnomalies.
+	AnomalyPenalty float64
+}
+
+// computeSincerity returns the sincerity index (0-100) and its class under
+// policy: SINCERE from SincereThreshold, BORDERLINE from
+// BorderlineThreshold, NOT_SINCERE below.
+func computeSincerity(in sincerityInputs, policy sincerityPolicy) (float64, string) {
+	sincerityIndex := 100.0
+	sincerityIndex -= (float64(in.AttentionFails) * policy.AttentionFailPenalty)
+	sincerityIndex -= (float64(in.DistractionsChosen) * policy.DistractionPenalty)
+	if in.IntegrityScore != nil {
+		sincerityIndex -= (100 - *in.IntegrityScore) * policy.ProctoringWeight
+	}
+	sincerityIndex -= in.AnomalyPenalty
+	if sincerityIndex < 0 {
+		sincerityIndex = 0
+	}
+
+	var sincerityClass string
+	if sincerityIndex >= policy.SincereThreshold {
+		sincerityClass = "SINCERE"
+	} else if sincerityIndex >= policy.BorderlineThreshold {
+		sincerityClass = "BORDERLINE"
+	} else {
+		sincerityClass = "NOT_SINCERE"
+	}
+	return sincerityIndex, sincerityClass
+}
@@ -0,0 +1,111 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"fmt"
+)
+
+// Question categories that carry sincerity checks.
+const (
+	categoryAttentionCheck = "ATTENTION_CHECK"
+	categoryDistraction    = "DISTRACTION"
+)
+
+// optionMeta is the subset of assessment_question_options.metadata used for
+// sincerity: Distractor marks an option that must not be chosen, Safe marks
+// the expected answer on a DISTRACTION question.
+type optionMeta struct {
+	Distractor bool `json:"distractor"`
+	Safe       bool `json:"safe"`
+}
+
+// sincerityOption is one option of a question as the sincerity checks see it.
+type sincerityOption struct {
+	ID         int64
+	Correct    bool
+	Distractor bool
+	Safe       bool
+}
+
+func newSincerityOption(opt models.AssessmentQuestionOption) sincerityOption {
+	var meta optionMeta
+	if opt.Metadata != "" {
+		_ = json.Unmarshal([]byte(opt.Metadata), &meta)
+	}
+	return sincerityOption{ID: opt.ID, Correct: opt.IsCorrect, Distractor: meta.Distractor, Safe: meta.Safe}
+}
+
+// evaluateSincerityFlags decides whether an answer fails an attention check
+// and whether it chose a distractor.
+//
+// An attention check is failed unless the correct option is chosen (for
+// multi-select, exactly the correct set). A distractor is chosen when any
+// picked option is marked distractor. On a DISTRACTION question without
+// marked distractors, every option outside the expected set counts as one;
+// the expected set is the options marked safe, or else the correct ones. A
+// DISTRACTION question with none of these markers penalises nothing.
+func evaluateSincerityFlags(category string, options []sincerityOption, selected []int64, multi bool) (attentionFail bool, distractionChosen bool) {
+	byID := make(map[int64]sincerityOption, len(options))
+	var hasDistractor, hasSafe, hasCorrect bool
+	for _, opt := range options {
+		byID[opt.ID] = opt
+		hasDistractor = hasDistractor || opt.Distractor
+		hasSafe = hasSafe || opt.Safe
+		hasCorrect = hasCorrect || opt.Correct
+	}
+
+	if category == categoryAttentionCheck {
+		if multi {
+			scored := make([]scoredOption, 0, len(options))
+			for _, opt := range options {
+				scored = append(scored, scoredOption{ID: opt.ID, Correct: opt.Correct})
+			}
+			attentionFail = !selectionIsExactlyCorrect(scored, selected)
+		} else {
+			attentionFail = len(selected) == 0 || !byID[selected[0]].Correct
+		}
+	}
+
+	for _, id := range selected {
+		opt := byID[id]
+		switch {
+		case opt.Distractor:
+			distractionChosen = true
+		case category != categoryDistraction || hasDistractor:
+		case hasSafe:
+			distractionChosen = distractionChosen || !opt.Safe
+		case hasCorrect:
+			distractionChosen = distractionChosen || !opt.Correct
+		}
+	}
+	return attentionFail, distractionChosen
+}
+
+// applySincerityFlags evaluates the sincerity checks of a MAIN answer and
+// stores them on the row: SincerityFlag 1 (not sincere) when either check
+// trips, 2 otherwise.
+func applySincerityFlags(answerRecord *models.AssessmentAnswer, question models.AssessmentQuestion, options []models.AssessmentQuestionOption, selected []int64, multi bool) {
+	items := make([]sincerityOption, 0, len(options))
+	for _, opt := range options {
+		items = append(items, newSincerityOption(opt))
+	}
+	if question.Category == categoryDistraction && !hasSincerityMarkers(items) {
+		fmt.Printf("[SubmitAnswer] WARN: DISTRACTION Question %d has no distractor/safe/correct option; not penalised\n", question.ID)
+	}
+
+	answerRecord.IsAttentionFail, answerRecord.IsDistractionChosen = evaluateSincerityFlags(question.Category, items, selected, multi)
+	answerRecord.SincerityFlag = 2 // Sincere
+	if answerRecord.IsAttentionFail || answerRecord.IsDistractionChosen {
+		answerRecord.SincerityFlag = 1 // Not Sincere
+	}
+}
+
+func hasSincerityMarkers(options []sincerityOption) bool {
+	for _, opt := range options {
+		if opt.Distractor || opt.Safe || opt.Correct {
+			return true
+		}
+	}
+	return false
+}
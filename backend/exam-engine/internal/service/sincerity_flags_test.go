@@ -0,0 +1,66 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"testing"
+)
+
+func TestEvaluateSincerityFlags(t *testing.T) {
+	attention := []sincerityOption{{ID: 1}, {ID: 2, Correct: true}, {ID: 3}}
+	markedDistractor := []sincerityOption{{ID: 1}, {ID: 2, Distractor: true}, {ID: 3}}
+	markedSafe := []sincerityOption{{ID: 1, Safe: true}, {ID: 2}, {ID: 3}}
+	correctAsSafe := []sincerityOption{{ID: 1}, {ID: 2, Correct: true}}
+	unmarked := []sincerityOption{{ID: 1}, {ID: 2}}
+	multiAttention := []sincerityOption{{ID: 1, Correct: true}, {ID: 2, Correct: true}, {ID: 3}}
+
+	cases := []struct {
+		name           string
+		category       string
+		options        []sincerityOption
+		selected       []int64
+		multi          bool
+		wantAttention  bool
+		wantDistracted bool
+	}{
+		{"attention passed", categoryAttentionCheck, attention, []int64{2}, false, false, false},
+		{"attention failed", categoryAttentionCheck, attention, []int64{3}, false, true, false},
+		{"multi attention exact", categoryAttentionCheck, multiAttention, []int64{1, 2}, true, false, false},
+		{"multi attention partial", categoryAttentionCheck, multiAttention, []int64{1}, true, true, false},
+		{"distractor chosen", categoryDistraction, markedDistractor, []int64{2}, false, false, true},
+		{"distractor avoided", categoryDistraction, markedDistractor, []int64{3}, false, false, false},
+		{"safe option chosen", categoryDistraction, markedSafe, []int64{1}, false, false, false},
+		{"unsafe option chosen", categoryDistraction, markedSafe, []int64{2}, false, false, true},
+		{"correct option is the safe one", categoryDistraction, correctAsSafe, []int64{2}, false, false, false},
+		{"incorrect option without markers", categoryDistraction, correctAsSafe, []int64{1}, false, false, true},
+		{"distraction question without markers", categoryDistraction, unmarked, []int64{1}, false, false, false},
+		{"normal item", "FOCUS", attention, []int64{3}, false, false, false},
+		{"distractor option on a normal item", "FOCUS", markedDistractor, []int64{2}, false, false, true},
+		{"multi-select with one distractor", categoryDistraction, markedDistractor, []int64{1, 2}, true, false, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			attentionFail, distracted := evaluateSincerityFlags(c.category, c.options, c.selected, c.multi)
+			if attentionFail != c.wantAttention || distracted != c.wantDistracted {
+				t.Errorf("got (attention fail %v, distractor %v), want (%v, %v)", attentionFail, distracted, c.wantAttention, c.wantDistracted)
+			}
+		})
+	}
+}
+
+func TestApplySincerityFlagsResetsOnChange(t *testing.T) {
+	question := models.AssessmentQuestion{ID: 9, Category: categoryDistraction}
+	options := []models.AssessmentQuestionOption{
+		{ID: 1, Metadata: `{"safe": true}`},
+		{ID: 2, Metadata: "{}"},
+	}
+	rec := &models.AssessmentAnswer{}
+
+	applySincerityFlags(rec, question, options, []int64{2}, false)
+	if !rec.IsDistractionChosen || rec.SincerityFlag != 1 {
+		t.Fatalf("after unsafe pick: %+v", rec)
+	}
+	applySincerityFlags(rec, question, options, []int64{1}, false)
+	if rec.IsDistractionChosen || rec.SincerityFlag != 2 {
+		t.Errorf("changing to the safe option should clear the flag: %+v", rec)
+	}
+}
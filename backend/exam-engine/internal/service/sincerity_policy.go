@@ -0,0 +1,112 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// sincerityPolicy holds every number that goes into the sincerity index.
+// It is read from originbi_settings, category "sincerity": number rows named
+// after the json tags set the base policy, "policy_version" (string) names
+// it, and json rows "program_<id>" override any subset of fields for one
+// program.
+type sincerityPolicy struct {
+	Version string `json:"version"`
+
+	AttentionFailPenalty float64 `json:"attention_fail_penalty"`
+	DistractionPenalty   float64 `json:"distraction_penalty"`
+	// ProctoringWeight is how much of the integrity shortfall
+	// (100 - integrity score) comes off the index. It ships at 0, like the
+	// response-pattern weights, until the events are checked against real
+	// data.
+	ProctoringWeight float64 `json:"proctoring_weight"`
+
+	SincereThreshold    float64 `json:"sincere_threshold"`
+	BorderlineThreshold float64 `json:"borderline_threshold"`
+
+	anomalyPolicy
+	// MinReadSecondsByLevel overrides speeding_min_seconds per level number
+	// (rows "speeding_min_seconds_level_<n>").
+	MinReadSecondsByLevel map[int]float64 `json:"speeding_min_seconds_by_level,omitempty"`
+}
+
+// defaultSincerityPolicy is the model used before it became configurable.
+var defaultSincerityPolicy = sincerityPolicy{
+	Version:              "default",
+	AttentionFailPenalty: 20,
+	DistractionPenalty:   10,
+	SincereThreshold:     80,
+	BorderlineThreshold:  50,
+	anomalyPolicy:        defaultAnomalyPolicy,
+}
+
+// anomalies returns the anomaly policy for a level.
+func (p sincerityPolicy) anomalies(levelNumber int) anomalyPolicy {
+	a := p.anomalyPolicy
+	if v, ok := p.MinReadSecondsByLevel[levelNumber]; ok {
+		a.MinReadSeconds = v
+	}
+	return a
+}
+
+// sincerityPolicySet is the base policy plus the raw per-program overrides.
+type sincerityPolicySet struct {
+	base      sincerityPolicy
+	overrides map[int64]json.RawMessage
+}
+
+// buildSincerityPolicySet turns the category's rows into a policy set.
+// Unknown keys are ignored and malformed values keep the default.
+func buildSincerityPolicySet(rows []settingsRow) sincerityPolicySet {
+	set := sincerityPolicySet{base: defaultSincerityPolicy, overrides: programOverrideRows(rows)}
+	set.base.Version = policyVersionRow(rows, set.base.Version)
+	set.base.MinReadSecondsByLevel = map[int]float64{}
+
+	for _, row := range rows {
+		if row.ValueNumber == nil {
+			continue
+		}
+		if suffix, ok := strings.CutPrefix(row.SettingKey, "speeding_min_seconds_level_"); ok {
+			if level, err := strconv.Atoi(suffix); err == nil {
+				set.base.MinReadSecondsByLevel[level] = *row.ValueNumber
+			}
+			continue
+		}
+		applySettingField(&set.base, row.SettingKey, *row.ValueNumber)
+	}
+	return set
+}
+
+// forProgram returns the policy for a program. An override without its own
+// "version" is labelled "<base version>+program_<id>".
+func (set sincerityPolicySet) forProgram(programID int64) sincerityPolicy {
+	p := set.base
+	raw, ok := set.overrides[programID]
+	if !ok {
+		return p
+	}
+
+	levels := make(map[int]float64, len(p.MinReadSecondsByLevel))
+	for k, v := range p.MinReadSecondsByLevel {
+		levels[k] = v
+	}
+	p.MinReadSecondsByLevel = levels
+	p.Version = ""
+
+	if err := json.Unmarshal(raw, &p); err != nil {
+		fmt.Printf("[Sincerity] Ignoring override for Program %d: %v\n", programID, err)
+		return set.base
+	}
+	if p.Version == "" {
+		p.Version = fmt.Sprintf("%s+program_%d", set.base.Version, programID)
+	}
+	return p
+}
+
+// loadSincerityPolicy returns the policy for a program from the cached
+// "sincerity" settings category.
+func loadSincerityPolicy(programID int64) sincerityPolicy {
+	return buildSincerityPolicySet(policySettings.rows("sincerity")).forProgram(programID)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func sincerityNumberRow(key string, v float64) settingsRow {
+	return settingsRow{SettingKey: key, ValueType: "number", ValueNumber: &v}
+}
+
+func sincerityJSONRow(key, v string) settingsRow {
+	return settingsRow{SettingKey: key, ValueType: "json", ValueJSON: &v}
+}
+
+func TestBuildSincerityPolicySet(t *testing.T) {
+	version := "2026-03"
+	set := buildSincerityPolicySet([]settingsRow{
+		{SettingKey: "policy_version", ValueType: "string", ValueString: &version},
+		sincerityNumberRow("attention_fail_penalty", 25),
+		sincerityNumberRow("sincere_threshold", 85),
+		sincerityNumberRow("straight_line_min_run", 6),
+		sincerityNumberRow("straight_line_min_run_typo", 9),
+		sincerityNumberRow("flip_threshold", 2.5), // not an int: keeps the default
+		sincerityNumberRow("speeding_min_seconds_level_2", 4),
+		sincerityJSONRow("program_7", `{"distraction_penalty": 5, "speeding_min_seconds_by_level": {"3": 6}}`),
+		sincerityJSONRow("program_8", `{"version": "pilot-1", "sincere_threshold": 70}`),
+		sincerityJSONRow("program_9", `not json`),
+	})
+
+	base := set.forProgram(1)
+	if base.Version != "2026-03" || base.AttentionFailPenalty != 25 || base.SincereThreshold != 85 {
+		t.Errorf("base = %+v", base)
+	}
+	if base.DistractionPenalty != 10 || base.StraightLineMinRun != 6 || base.FlipThreshold != 3 {
+		t.Errorf("base kept wrong defaults: %+v", base)
+	}
+	if got := base.anomalies(2).MinReadSeconds; got != 4 {
+		t.Errorf("level 2 min read = %v, want 4", got)
+	}
+	if got := base.anomalies(1).MinReadSeconds; got != 2 {
+		t.Errorf("level 1 min read = %v, want 2", got)
+	}
+
+	p7 := set.forProgram(7)
+	if p7.Version != "2026-03+program_7" || p7.DistractionPenalty != 5 || p7.AttentionFailPenalty != 25 {
+		t.Errorf("program 7 = %+v", p7)
+	}
+	if p7.anomalies(3).MinReadSeconds != 6 || p7.anomalies(2).MinReadSeconds != 4 {
+		t.Errorf("program 7 levels = %v", p7.MinReadSecondsByLevel)
+	}
+	if _, leaked := set.forProgram(1).MinReadSecondsByLevel[3]; leaked {
+		t.Error("program override leaked into the base policy")
+	}
+
+	if p8 := set.forProgram(8); p8.Version != "pilot-1" || p8.SincereThreshold != 70 {
+		t.Errorf("program 8 = %+v", p8)
+	}
+	if p9 := set.forProgram(9); p9.Version != "2026-03" {
+		t.Errorf("malformed override should fall back to base, got %+v", p9)
+	}
+}
+
+func TestComputeSincerityUsesPolicy(t *testing.T) {
+	strict := defaultSincerityPolicy
+	strict.AttentionFailPenalty = 30
+	strict.SincereThreshold = 90
+
+	if index, class := computeSincerity(sincerityInputs{AttentionFails: 1}, strict); index != 70 || class != "BORDERLINE" {
+		t.Errorf("got (%v, %s), want (70, BORDERLINE)", index, class)
+	}
+}
+
+func TestSincerityPolicyCacheReloads(t *testing.T) {
+	now := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	penalty := 20.0
+	fail := false
+	loads := 0
+	cache := &settingsCategoryCache{
+		now: func() time.Time { return now },
+		load: func(category string) ([]settingsRow, error) {
+			loads++
+			if fail {
+				return nil, errors.New("db down")
+			}
+			return []settingsRow{sincerityNumberRow("attention_fail_penalty", penalty)}, nil
+		},
+	}
+	get := func() sincerityPolicySet { return buildSincerityPolicySet(cache.rows("sincerity")) }
+
+	if got := get().base.AttentionFailPenalty; got != 20 {
+		t.Fatalf("penalty = %v, want 20", got)
+	}
+	penalty = 15
+	now = now.Add(policySettingsTTL / 2)
+	if got := get().base.AttentionFailPenalty; got != 20 || loads != 1 {
+		t.Errorf("within TTL: penalty = %v, loads = %d", got, loads)
+	}
+	now = now.Add(policySettingsTTL)
+	if got := get().base.AttentionFailPenalty; got != 15 || loads != 2 {
+		t.Errorf("after TTL: penalty = %v, loads = %d", got, loads)
+	}
+	fail = true
+	now = now.Add(policySettingsTTL)
+	if got := get().base.AttentionFailPenalty; got != 15 {
+		t.Errorf("failed reload should keep the last policy, got %v", got)
+	}
+}
@@ -0,0 +1,54 @@
+package service
+
+import "testing"
+
+func TestIntegrityScore(t *testing.T) {
+	cases := []struct {
+		name     string
+		counters map[string]int64
+		want     float64
+	}{
+		{"no events", map[string]int64{}, 100},
+		{"a few tab switches", map[string]int64{eventTabSwitch: 3}, 85},
+		{"capped per type", map[string]int64{eventTabSwitch: 50}, 60},
+		{"mixed", map[string]int64{eventPaste: 2, eventFocusLost: 5, eventCopy: 1}, 65},
+		{"unknown types ignored", map[string]int64{"SCREENSHOT": 9}, 100},
+		{"floored at zero", map[string]int64{eventTabSwitch: 99, eventPaste: 99, eventFullscreenExit: 99, eventCopy: 99, eventFocusLost: 99}, 0},
+	}
+	for _, c := range cases {
+		if got := integrityScore(c.counters); got != c.want {
+			t.Errorf("%s: integrityScore = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestComputeSincerity(t *testing.T) {
+	integrity := func(v float64) *float64 { return &v }
+	cases := []struct {
+		name      string
+		in        sincerityInputs
+		wantIndex float64
+		wantClass string
+	}{
+		{"clean", sincerityInputs{}, 100, "SINCERE"},
+		{"one attention fail", sincerityInputs{AttentionFails: 1}, 80, "SINCERE"},
+		{"fail and distraction", sincerityInputs{AttentionFails: 1, DistractionsChosen: 1}, 70, "BORDERLINE"},
+		{"proctoring only", sincerityInputs{IntegrityScore: integrity(60)}, 80, "SINCERE"},
+		{"proctoring tips the class", sincerityInputs{AttentionFails: 1, IntegrityScore: integrity(90)}, 75, "BORDERLINE"},
+		{"floored", sincerityInputs{AttentionFails: 4, DistractionsChosen: 3, IntegrityScore: integrity(0)}, 0, "NOT_SINCERE"},
+		{"answer patterns", sincerityInputs{DistractionsChosen: 1, AnomalyPenalty: 15}, 75, "BORDERLINE"},
+	}
+	weighted := defaultSincerityPolicy
+	weighted.ProctoringWeight = 0.5
+	for _, c := range cases {
+		index, class := computeSincerity(c.in, weighted)
+		if index != c.wantIndex || class != c.wantClass {
+			t.Errorf("%s: got (%v, %s), want (%v, %s)", c.name, index, class, c.wantIndex, c.wantClass)
+		}
+	}
+
+	// Proctoring lowers nothing until its weight is set.
+	if index, _ := computeSincerity(sincerityInputs{IntegrityScore: integrity(0)}, defaultSincerityPolicy); index != 100 {
+		t.Errorf("default policy: index = %v, want 100", index)
+	}
+}
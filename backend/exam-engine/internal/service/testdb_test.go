@@ -0,0 +1,66 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"exam-engine/internal/repository"
+	"fmt"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory SQLite database with the exam tables and
+// installs it as repository.DB for the duration of the test. SQLite has no
+// row locks, but it runs the same transactions and savepoints, which is what
+// these tests are about; Postgres-only queries are not exercised.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", strings.ReplaceAll(t.Name(), "/", "_"))
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open test db: %v", err)
+	}
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1) // one connection keeps the in-memory database alive
+
+	// The models' column types and defaults are written for Postgres.
+	postgresTypes := strings.NewReplacer("now()", "CURRENT_TIMESTAMP", "timestamp with time zone", "datetime")
+	db.Callback().Raw().Before("gorm:raw").Register("test:sqlite_ddl", func(tx *gorm.DB) {
+		if sql := tx.Statement.SQL.String(); strings.HasPrefix(sql, "CREATE TABLE") {
+			tx.Statement.SQL.Reset()
+			tx.Statement.SQL.WriteString(postgresTypes.Replace(sql))
+		}
+	})
+	if err := db.AutoMigrate(
+		&models.AssessmentAttempt{}, &models.AssessmentAnswer{}, &models.AssessmentLevel{},
+		&models.AssessmentQuestion{}, &models.AssessmentQuestionOption{}, &models.AssessmentSession{},
+	); err != nil {
+		t.Fatalf("migrate test db: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE originbi_settings (
+		category TEXT, setting_key TEXT, value_type TEXT, value_string TEXT,
+		value_boolean BOOLEAN, value_number NUMERIC, value_json TEXT)`).Error; err != nil {
+		t.Fatalf("create settings: %v", err)
+	}
+
+	previous := repository.DB
+	repository.DB = db
+	t.Cleanup(func() {
+		repository.DB = previous
+		sqlDB.Close()
+	})
+	return db
+}
+
+// mustCreate inserts rows into the test database.
+func mustCreate(t *testing.T, db *gorm.DB, rows ...interface{}) {
+	t.Helper()
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			t.Fatalf("create %T: %v", row, err)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"time"
+)
+
+// answerGracePeriod absorbs network latency for answers the candidate
+// submitted just before the clock ran out.
+const answerGracePeriod = 30 * time.Second
+
+// levelDeadline returns when an attempt started at `from` must be finished for
+// the given level, or nil when the level is untimed (duration_minutes <= 0).
+func levelDeadline(level models.AssessmentLevel, from time.Time) *time.Time {
+	if level.DurationMinutes <= 0 {
+		return nil
+	}
+	deadline := from.Add(time.Duration(level.DurationMinutes) * time.Minute)
+	return &deadline
+}
+
+// attemptTiming builds the server-authoritative clock the runner displays.
+// RemainingSeconds is nil for untimed attempts and never negative.
+func attemptTiming(attempt models.AssessmentAttempt, now time.Time) models.AttemptTiming {
+	timing := models.AttemptTiming{
+		StartedAt:    attempt.StartedAt,
+		MustFinishBy: attempt.MustFinishBy,
+		ServerTime:   now,
+	}
+	if attempt.MustFinishBy != nil {
+		remaining := int64(attempt.MustFinishBy.Sub(now) / time.Second)
+		if remaining < 0 {
+			remaining = 0
+		}
+		timing.RemainingSeconds = &remaining
+	}
+	return timing
+}
+
+// isPastDeadline reports whether an answer arriving at `now` is too late,
+// allowing answerGracePeriod after must_finish_by.
+func isPastDeadline(attempt models.AssessmentAttempt, now time.Time) bool {
+	return attempt.MustFinishBy != nil && now.After(attempt.MustFinishBy.Add(answerGracePeriod))
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"exam-engine/internal/models"
+	"testing"
+	"time"
+)
+
+func TestAttemptTiming(t *testing.T) {
+	now := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	level := models.AssessmentLevel{DurationMinutes: 40}
+
+	deadline := levelDeadline(level, now)
+	if deadline == nil || !deadline.Equal(now.Add(40*time.Minute)) {
+		t.Fatalf("levelDeadline = %v, want start+40m", deadline)
+	}
+	if levelDeadline(models.AssessmentLevel{}, now) != nil {
+		t.Errorf("untimed level should have no deadline")
+	}
+
+	attempt := models.AssessmentAttempt{StartedAt: &now, MustFinishBy: deadline}
+	timing := attemptTiming(attempt, now.Add(10*time.Minute))
+	if timing.RemainingSeconds == nil || *timing.RemainingSeconds != 30*60 {
+		t.Errorf("remaining = %v, want 1800", timing.RemainingSeconds)
+	}
+	if late := attemptTiming(attempt, now.Add(time.Hour)); *late.RemainingSeconds != 0 {
+		t.Errorf("remaining after deadline = %d, want 0", *late.RemainingSeconds)
+	}
+	if attemptTiming(models.AssessmentAttempt{}, now).RemainingSeconds != nil {
+		t.Errorf("untimed attempt should report nil remaining_seconds")
+	}
+
+	if isPastDeadline(attempt, deadline.Add(answerGracePeriod)) {
+		t.Errorf("answer within grace period rejected")
+	}
+	if !isPastDeadline(attempt, deadline.Add(answerGracePeriod+time.Second)) {
+		t.Errorf("answer after grace period accepted")
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"encoding/json"
+	"exam-engine/internal/models"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Response types a question can declare in its metadata (response_type).
+const (
+	responseText    = "text"
+	responseNumber  = "number"
+	responseEssay   = "essay"
+	responseRanking = "ranking"
+)
+
+// Scoring status recorded with a typed response.
+const (
+	scoringAuto          = "AUTO"           // scored by the engine
+	scoringPendingManual = "PENDING_MANUAL" // waits for a reviewer
+	scoringUnscored      = "UNSCORED"       // no answer key configured
+)
+
+const (
+	defaultTextMaxLength  = 1000
+	defaultEssayMaxLength = 20000
+)
+
+// typedResponse is a validated text, number, essay or ranking answer.
+type typedResponse struct {
+	Type    string
+	Text    string
+	Number  float64
+	Ranking []int64
+}
+
+// responseScorer scores a typed response against the question's answer key
+// and reports how it was scored (scoringAuto, scoringPendingManual,
+// scoringUnscored).
+type responseScorer func(resp typedResponse, meta questionMeta) (float64, string)
+
+// responseScorers is the scoring hook per response type.
+var responseScorers = map[string]responseScorer{
+	responseText:    scoreTextResponse,
+	responseNumber:  scoreNumberResponse,
+	responseEssay:   deferToManualScoring,
+	responseRanking: scoreRankingResponse,
+}
+
+func isTypedResponse(meta questionMeta) bool {
+	_, ok := responseScorers[meta.ResponseType]
+	return ok
+}
+
+// applyTypedResponse stores the answer of a text, number, essay or ranking
+// question in answer_text plus the answer metadata and scores it. It reports
+// false, leaving the row alone, when the question is an option-choice one.
+func applyTypedResponse(tx *gorm.DB, answerRecord *models.AssessmentAnswer, answerMeta map[string]interface{}, req models.StudentAnswer) (bool, error) {
+	delete(answerMeta, "response")
+
+	var meta questionMeta
+	var optionIDs []int64
+	switch {
+	case answerRecord.MainQuestionID != nil && *answerRecord.MainQuestionID == req.QuestionID:
+		var question models.AssessmentQuestion
+		tx.Select("id, metadata").First(&question, *answerRecord.MainQuestionID)
+		meta = parseQuestionMeta(question.Metadata)
+		if meta.ResponseType == responseRanking {
+			tx.Model(&models.AssessmentQuestionOption{}).
+				Where("question_id = ? AND is_active = true AND is_deleted = false", question.ID).
+				Pluck("id", &optionIDs)
+		}
+	case answerRecord.OpenQuestionID != nil && *answerRecord.OpenQuestionID == req.QuestionID:
+		var question models.OpenQuestion
+		tx.Select("id, metadata").First(&question, *answerRecord.OpenQuestionID)
+		meta = parseQuestionMeta(question.Metadata)
+		if meta.ResponseType == responseRanking {
+			tx.Model(&models.OpenQuestionOption{}).
+				Where("open_question_id = ? AND is_active = true AND is_deleted = false", question.ID).
+				Pluck("id", &optionIDs)
+		}
+	}
+	if !isTypedResponse(meta) {
+		return false, nil
+	}
+
+	resp, err := parseTypedResponse(req, meta, optionIDs)
+	if err != nil {
+		return true, err
+	}
+	score, status := responseScorers[resp.Type](resp, meta)
+
+	stored := map[string]interface{}{
+		"type":           resp.Type,
+		"scoring_status": status,
+	}
+	switch resp.Type {
+	case responseNumber:
+		answerRecord.AnswerText = strconv.FormatFloat(resp.Number, 'f', -1, 64)
+		stored["number"] = resp.Number
+	case responseRanking:
+		b, _ := json.Marshal(resp.Ranking)
+		answerRecord.AnswerText = string(b)
+		stored["ranking"] = resp.Ranking
+	default:
+		answerRecord.AnswerText = resp.Text
+	}
+	answerMeta["response"] = stored
+
+	answerRecord.MainOptionID = nil
+	answerRecord.OpenOptionID = nil
+	answerRecord.IsMultipleSelection = false
+	answerRecord.AnswerScore = score
+	// Attention and distraction checks are about chosen options; flags left
+	// by an earlier option answer on this row must not count any more.
+	answerRecord.IsAttentionFail = false
+	answerRecord.IsDistractionChosen = false
+	answerRecord.SincerityFlag = 2
+	return true, nil
+}
+
+// parseTypedResponse validates the typed fields of an answer against the
+// question's response type and limits.
+func parseTypedResponse(req models.StudentAnswer, meta questionMeta, optionIDs []int64) (typedResponse, error) {
+	if req.ResponseType != "" && req.ResponseType != meta.ResponseType {
+		return typedResponse{}, invalidAnswer(fmt.Sprintf("this question expects a %s response", meta.ResponseType))
+	}
+	resp := typedResponse{Type: meta.ResponseType}
+
+	switch meta.ResponseType {
+	case responseText, responseEssay:
+		if req.AnswerText == nil || strings.TrimSpace(*req.AnswerText) == "" {
+			return resp, invalidAnswer("answer_text is required")
+		}
+		limit := meta.MaxLength
+		if limit <= 0 {
+			limit = defaultTextMaxLength
+			if meta.ResponseType == responseEssay {
+				limit = defaultEssayMaxLength
+			}
+		}
+		if utf8.RuneCountInString(*req.AnswerText) > limit {
+			return resp, invalidAnswer(fmt.Sprintf("answer_text is limited to %d characters", limit))
+		}
+		resp.Text = strings.TrimSpace(*req.AnswerText)
+
+	case responseNumber:
+		if req.AnswerNumber == nil {
+			return resp, invalidAnswer("answer_number is required")
+		}
+		n := *req.AnswerNumber
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return resp, invalidAnswer("answer_number must be a finite number")
+		}
+		if meta.MinValue != nil && n < *meta.MinValue {
+			return resp, invalidAnswer(fmt.Sprintf("answer_number must be at least %v", *meta.MinValue))
+		}
+		if meta.MaxValue != nil && n > *meta.MaxValue {
+			return resp, invalidAnswer(fmt.Sprintf("answer_number must be at most %v", *meta.MaxValue))
+		}
+		resp.Number = n
+
+	case responseRanking:
+		if !isPermutation(req.Ranking, optionIDs) {
+			return resp, invalidAnswer("ranking must list every option of the question exactly once")
+		}
+		resp.Ranking = req.Ranking
+	}
+	return resp, nil
+}
+
+func isPermutation(ranking, optionIDs []int64) bool {
+	if len(ranking) == 0 || len(ranking) != len(optionIDs) {
+		return false
+	}
+	remaining := make(map[int64]bool, len(optionIDs))
+	for _, id := range optionIDs {
+		remaining[id] = true
+	}
+	for _, id := range ranking {
+		if !remaining[id] {
+			return false
+		}
+		delete(remaining, id)
+	}
+	return true
+}
+
+func fullPoints(meta questionMeta) float64 {
+	if meta.Points > 0 {
+		return meta.Points
+	}
+	return 1
+}
+
+// scoreTextResponse gives full points for an exact match with one of the
+// accepted answers (trimmed, case-insensitive unless case_sensitive) or a
+// match of answer_pattern.
+func scoreTextResponse(resp typedResponse, meta questionMeta) (float64, string) {
+	if len(meta.AcceptedAnswers) == 0 && meta.AnswerPattern == "" {
+		return 0, scoringUnscored
+	}
+	for _, accepted := range meta.AcceptedAnswers {
+		accepted = strings.TrimSpace(accepted)
+		if accepted == resp.Text || (!meta.CaseSensitive && strings.EqualFold(accepted, resp.Text)) {
+			return fullPoints(meta), scoringAuto
+		}
+	}
+	if meta.AnswerPattern != "" {
+		pattern := meta.AnswerPattern
+		if !meta.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			fmt.Printf("[SubmitAnswer] WARN: invalid answer_pattern %q: %v\n", meta.AnswerPattern, err)
+			return 0, scoringUnscored
+		}
+		if re.MatchString(resp.Text) {
+			return fullPoints(meta), scoringAuto
+		}
+	}
+	return 0, scoringAuto
+}
+
+// scoreNumberResponse gives full points when the answer is within tolerance
+// of correct_number.
+func scoreNumberResponse(resp typedResponse, meta questionMeta) (float64, string) {
+	if meta.CorrectNumber == nil {
+		return 0, scoringUnscored
+	}
+	if math.Abs(resp.Number-*meta.CorrectNumber) <= math.Abs(meta.Tolerance) {
+		return fullPoints(meta), scoringAuto
+	}
+	return 0, scoringAuto
+}
+
+// scoreRankingResponse gives credit for every option ranked in the same
+// position as in correct_ranking.
+func scoreRankingResponse(resp typedResponse, meta questionMeta) (float64, string) {
+	if len(meta.CorrectRanking) == 0 {
+		return 0, scoringUnscored
+	}
+	matched := 0
+	for i, id := range resp.Ranking {
+		if i < len(meta.CorrectRanking) && meta.CorrectRanking[i] == id {
+			matched++
+		}
+	}
+	return fullPoints(meta) * float64(matched) / float64(len(meta.CorrectRanking)), scoringAuto
+}
+
+// deferToManualScoring leaves essays at zero until a reviewer scores them.
+func deferToManualScoring(resp typedResponse, meta questionMeta) (float64, string) {
+	return 0, scoringPendingManual
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"exam-engine/internal/models"
+	"testing"
+)
+
+func float64Ptr(v float64) *float64 { return &v }
+
+func TestParseTypedResponse(t *testing.T) {
+	options := []int64{10, 11, 12}
+	cases := []struct {
+		name string
+		req  models.StudentAnswer
+		meta questionMeta
+		fail bool
+	}{
+		{"text", models.StudentAnswer{AnswerText: strPtr(" Paris ")}, questionMeta{ResponseType: responseText}, false},
+		{"blank text", models.StudentAnswer{AnswerText: strPtr("  ")}, questionMeta{ResponseType: responseText}, true},
+		{"text too long", models.StudentAnswer{AnswerText: strPtr("abcdef")}, questionMeta{ResponseType: responseText, MaxLength: 5}, true},
+		{"type mismatch", models.StudentAnswer{ResponseType: responseNumber, AnswerText: strPtr("x")}, questionMeta{ResponseType: responseText}, true},
+		{"number in range", models.StudentAnswer{AnswerNumber: float64Ptr(5)}, questionMeta{ResponseType: responseNumber, MinValue: float64Ptr(0), MaxValue: float64Ptr(10)}, false},
+		{"number out of range", models.StudentAnswer{AnswerNumber: float64Ptr(11)}, questionMeta{ResponseType: responseNumber, MaxValue: float64Ptr(10)}, true},
+		{"missing number", models.StudentAnswer{}, questionMeta{ResponseType: responseNumber}, true},
+		{"full ranking", models.StudentAnswer{Ranking: []int64{12, 10, 11}}, questionMeta{ResponseType: responseRanking}, false},
+		{"partial ranking", models.StudentAnswer{Ranking: []int64{12, 10}}, questionMeta{ResponseType: responseRanking}, true},
+		{"repeated ranking", models.StudentAnswer{Ranking: []int64{12, 12, 11}}, questionMeta{ResponseType: responseRanking}, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, err := parseTypedResponse(c.req, c.meta, options)
+			if !c.fail {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			var examErr *ExamError
+			if !errors.As(err, &examErr) || examErr.Code != ErrCodeInvalidAnswer {
+				t.Fatalf("err = %v, want %s", err, ErrCodeInvalidAnswer)
+			}
+		})
+	}
+}
+
+func TestTypedResponseScorers(t *testing.T) {
+	cases := []struct {
+		name       string
+		resp       typedResponse
+		meta       questionMeta
+		wantScore  float64
+		wantStatus string
+	}{
+		{"text exact, case-insensitive", typedResponse{Type: responseText, Text: "paris"}, questionMeta{AcceptedAnswers: []string{"Paris"}, Points: 2}, 2, scoringAuto},
+		{"text case-sensitive miss", typedResponse{Type: responseText, Text: "paris"}, questionMeta{AcceptedAnswers: []string{"Paris"}, CaseSensitive: true}, 0, scoringAuto},
+		{"text regex", typedResponse{Type: responseText, Text: "colour"}, questionMeta{AnswerPattern: `^colou?r$`}, 1, scoringAuto},
+		{"text without key", typedResponse{Type: responseText, Text: "x"}, questionMeta{}, 0, scoringUnscored},
+		{"number within tolerance", typedResponse{Type: responseNumber, Number: 3.14}, questionMeta{CorrectNumber: float64Ptr(3.1416), Tolerance: 0.01}, 1, scoringAuto},
+		{"number outside tolerance", typedResponse{Type: responseNumber, Number: 3}, questionMeta{CorrectNumber: float64Ptr(3.1416), Tolerance: 0.01}, 0, scoringAuto},
+		{"ranking partial", typedResponse{Type: responseRanking, Ranking: []int64{1, 3, 2, 4}}, questionMeta{CorrectRanking: []int64{1, 2, 3, 4}, Points: 4}, 2, scoringAuto},
+		{"essay deferred", typedResponse{Type: responseEssay, Text: "..."}, questionMeta{}, 0, scoringPendingManual},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			score, status := responseScorers[c.resp.Type](c.resp, c.meta)
+			if score != c.wantScore || status != c.wantStatus {
+				t.Errorf("got (%v, %s), want (%v, %s)", score, status, c.wantScore, c.wantStatus)
+			}
+		})
+	}
+}
+
+func TestTypedResponseClearsSincerityFlags(t *testing.T) {
+	db := newTestDB(t)
+	f := seedBatchAttempt(t, db, 2)
+	s := NewExamService()
+	countingCompletions(s)
+
+	// An earlier option answer failed an attention check; the question has
+	// since become a text question.
+	db.Model(&models.AssessmentAnswer{}).Where("id = ?", f.answers[0]).
+		Updates(map[string]interface{}{"is_attention_fail": true, "is_distraction_chosen": true, "sincerity_flag": 1, "status": "ANSWERED"})
+	db.Model(&models.AssessmentQuestion{}).Where("id = ?", f.questions[0]).Update("metadata", `{"response_type": "text"}`)
+
+	req := f.item(0, "k1")
+	req.SelectedOption = 0
+	req.AnswerText = strPtr("Paris")
+	if _, err := s.SubmitAnswer(req, Caller{UserID: 7}); err != nil {
+		t.Fatalf("text answer: %v", err)
+	}
+
+	var answer models.AssessmentAnswer
+	db.First(&answer, f.answers[0])
+	if answer.IsAttentionFail || answer.IsDistractionChosen || answer.SincerityFlag != 2 {
+		t.Errorf("flags = attention %v, distraction %v, sincerity %d; want cleared",
+			answer.IsAttentionFail, answer.IsDistractionChosen, answer.SincerityFlag)
+	}
+	if answer.AnswerText != "Paris" || answer.MainOptionID != nil {
+		t.Errorf("answer = %q option %v", answer.AnswerText, answer.MainOptionID)
+	}
+}
@@ -0,0 +1,30 @@
+-- ============================================================
+-- Migration 033: Auto-submit timed-out attempts
+--
+-- Lets the exam-engine scheduler score IN_PROGRESS attempts whose
+-- time is up (must_finish_by or expires_at passed) instead of only
+-- marking them PARTIALLY_EXPIRED - provided the candidate answered
+-- more of the level than auto_submit_min_coverage (strictly above).
+-- Scored attempts go through the normal completion pipeline (DISC
+-- trait, report number, next-level unlock) with completion_mode =
+-- 'AUTO_SUBMIT'.
+--
+-- The decision and coverage are recorded on the attempt metadata
+-- under "auto_submit", in the same transaction as the completion or
+-- expiry. Off by default, so applying this migration changes
+-- nothing until an admin enables it.
+-- ============================================================
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_boolean, label, description, display_order)
+VALUES ('assessment', 'auto_submit_expired_attempts', 'boolean', false,
+        'Auto-submit Timed-out Attempts',
+        'When enabled, attempts still IN_PROGRESS after their deadline are scored with the answers given so far, provided answered coverage exceeds the minimum below. Otherwise they are marked PARTIALLY_EXPIRED as before.',
+        12)
+ON CONFLICT (category, setting_key) DO NOTHING;
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_number, label, description, display_order)
+VALUES ('assessment', 'auto_submit_min_coverage', 'number', 80,
+        'Auto-submit Minimum Coverage (%)',
+        'A timed-out attempt is auto-submitted and scored only when more than this percentage of its questions was answered.',
+        13)
+ON CONFLICT (category, setting_key) DO NOTHING;
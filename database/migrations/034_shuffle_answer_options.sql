@@ -0,0 +1,33 @@
+-- ============================================================
+-- Migration 034: Per-candidate option order
+--
+-- The exam-engine shows each candidate the options of a question in
+-- the order stored in assessment_answers.question_options_order, a
+-- shuffle seeded from the attempt and question so it is stable across
+-- reloads but differs between candidates. The admin and corporate
+-- services leave the column empty when they generate a paper; the
+-- engine fills every empty row the first time the paper is loaded
+-- (Level 2 rows when they are generated), whatever the attempt status,
+-- and never reshuffles afterwards.
+--
+-- Those services used to store an unseeded shuffle of [1,2,3,4]. It is
+-- cleared here for attempts that have not started, so they get the
+-- seeded order; attempts under way keep the order already shown.
+--
+-- On by default to counter position bias on forced-choice items;
+-- turning it off shows display_order and ignores stored orders.
+-- ============================================================
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_boolean, label, description, display_order)
+VALUES ('assessment', 'shuffle_answer_options', 'boolean', true,
+        'Shuffle Answer Options',
+        'When enabled, each candidate sees the options of a question in their own reproducible order instead of display_order, reducing position bias on forced-choice items.',
+        14)
+ON CONFLICT (category, setting_key) DO NOTHING;
+
+UPDATE assessment_answers
+SET question_options_order = NULL
+WHERE question_options_order IS NOT NULL
+  AND assessment_attempt_id IN (
+      SELECT id FROM assessment_attempts WHERE status IN ('NOT_STARTED', 'NOT_YET_STARTED')
+  );
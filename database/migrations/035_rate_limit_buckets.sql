@@ -0,0 +1,18 @@
+-- ============================================================
+-- Migration 035: Shared rate-limit buckets for the exam-engine
+--
+-- Backing store for RATE_LIMIT_BACKEND=postgres, used when several
+-- exam-engine replicas must share one token bucket per key
+-- ("<route>:<attempt|ip>:<value>"). Rows are refreshed in place by a
+-- single upsert per request and pruned by the service once idle.
+-- UNLOGGED: losing buckets on a crash only resets the limits.
+-- ============================================================
+
+CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+    bucket_key  VARCHAR(200)     PRIMARY KEY,
+    tokens      DOUBLE PRECISION NOT NULL,
+    allowed     BOOLEAN          NOT NULL DEFAULT true,
+    updated_at  TIMESTAMPTZ      NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_rate_limit_buckets_updated
+    ON rate_limit_buckets (updated_at);
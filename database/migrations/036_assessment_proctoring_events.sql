@@ -0,0 +1,30 @@
+-- ============================================================
+-- Migration 036: Proctoring events
+--
+-- Client-side integrity signals (tab switches, leaving fullscreen,
+-- paste/copy, focus loss) sent in batches by the exam frontend to
+-- POST /api/v1/exam/attempts/:id/events. The exam-engine rolls
+-- per-type counters and an integrity score (0-100) into
+-- assessment_attempts.metadata->'proctoring'; the score is blended
+-- into the sincerity index when the attempt completes, weighted by
+-- sincerity/proctoring_weight (0 until enabled, see migration 039).
+--
+-- client_event_id lets the frontend retry a batch safely.
+-- ============================================================
+
+CREATE TABLE IF NOT EXISTS assessment_proctoring_events (
+    id                      BIGSERIAL    PRIMARY KEY,
+    assessment_attempt_id   BIGINT       NOT NULL REFERENCES assessment_attempts(id),
+    user_id                 BIGINT       NOT NULL,
+    event_type              VARCHAR(40)  NOT NULL,
+    occurred_at             TIMESTAMPTZ  NOT NULL,
+    duration_ms             INTEGER      NOT NULL DEFAULT 0,
+    client_event_id         VARCHAR(100),
+    metadata                JSONB        NOT NULL DEFAULT '{}',
+    created_at              TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_proctoring_events_attempt
+    ON assessment_proctoring_events (assessment_attempt_id, event_type);
+CREATE UNIQUE INDEX IF NOT EXISTS uq_proctoring_events_client_id
+    ON assessment_proctoring_events (assessment_attempt_id, client_event_id)
+    WHERE client_event_id IS NOT NULL;
@@ -0,0 +1,32 @@
+-- ============================================================
+-- Migration 037: Single-active-device lock
+--
+-- When enabled, starting or resuming an attempt issues a session
+-- token bound to the browser's device fingerprint and IP; answers
+-- must present it (X-Attempt-Session / X-Device-Fingerprint).
+-- Opening the attempt on a second device is refused until the
+-- candidate takes it over, which revokes the first device's token.
+-- Token and fingerprint hashes and the switch log live in
+-- assessment_attempts.metadata; no schema change is needed.
+--
+-- A request from the locked device with a different client IP is
+-- only logged, since mobile and school networks change addresses
+-- mid-exam; device_lock_strict_ip refuses it (IP_CHANGED) instead.
+-- Client IPs come from X-Forwarded-For only for TRUSTED_PROXIES.
+--
+-- Both off by default: the runner must send a fingerprint first.
+-- ============================================================
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_boolean, label, description, display_order)
+VALUES ('assessment', 'device_lock_enabled', 'boolean', false,
+        'Single Device Lock',
+        'When enabled, an attempt can only be answered from the device that started it; moving to another device requires an explicit takeover, which is logged on the attempt.',
+        15)
+ON CONFLICT (category, setting_key) DO NOTHING;
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_boolean, label, description, display_order)
+VALUES ('assessment', 'device_lock_strict_ip', 'boolean', false,
+        'Device Lock: Refuse IP Changes',
+        'When enabled together with the single device lock, requests from the locked device are refused after its IP address changes until the exam is resumed.',
+        16)
+ON CONFLICT (category, setting_key) DO NOTHING;
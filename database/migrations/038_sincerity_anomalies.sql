@@ -0,0 +1,36 @@
+-- ============================================================
+-- Migration 038: Response-pattern signals in sincerity
+--
+-- On completion the exam-engine now looks at how an attempt was
+-- answered, not only at attention checks and distractors:
+--   speeding        answers quicker than a minimum read time
+--   straight-lining long runs on the same option position
+--   flipping        answers changed many times
+-- Each weight is the number of points taken off the sincerity
+-- index when every answered question shows the signal; lower
+-- rates scale it down. The breakdown is stored on the attempt
+-- under metadata.sincerity_anomalies.
+--
+-- The weights ship as 0: the signals are recorded but no sincerity
+-- index changes until an operator raises a weight here (and bumps
+-- policy_version, so new reports can be told from old ones).
+--
+-- speeding_min_seconds can be overridden per level with
+-- speeding_min_seconds_level_<n> (e.g. speeding_min_seconds_level_2).
+-- ============================================================
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_number, label, description, display_order)
+VALUES
+    ('sincerity', 'speeding_min_seconds', 'number', 2,
+     'Minimum Read Time (s)', 'Answers given faster than this count as speeding. Answers with no recorded time are ignored.', 1),
+    ('sincerity', 'speeding_weight', 'number', 0,
+     'Speeding Weight', 'Sincerity points removed when every answer is speeding.', 2),
+    ('sincerity', 'straight_line_min_run', 'number', 8,
+     'Straight-Lining Run', 'Consecutive answers on the same option position that count as straight-lining.', 3),
+    ('sincerity', 'straight_line_weight', 'number', 0,
+     'Straight-Lining Weight', 'Sincerity points removed when every answer is part of a straight-lined run.', 4),
+    ('sincerity', 'flip_threshold', 'number', 3,
+     'Answer Flip Threshold', 'An answer changed this many times or more counts as flipping.', 5),
+    ('sincerity', 'flip_weight', 'number', 0,
+     'Flipping Weight', 'Sincerity points removed when every answer was flipped.', 6)
+ON CONFLICT (category, setting_key) DO NOTHING;
@@ -0,0 +1,41 @@
+-- ============================================================
+-- Migration 039: Configurable sincerity policy
+--
+-- The attention/distractor penalties and the class thresholds
+-- join the response-pattern settings of migration 038 in the
+-- 'sincerity' category. The exam-engine reloads the category at
+-- most once a minute, so edits apply without a restart.
+--
+-- proctoring_weight ships at 0, like the response-pattern weights:
+-- integrity scores are recorded but lower nothing until it is set.
+--
+-- Bump policy_version whenever the numbers change: every
+-- completed attempt stores the version and the full policy it was
+-- scored with (metadata.sincerity_policy_version /
+-- metadata.sincerity_policy).
+--
+-- Per-program overrides are json rows keyed program_<program_id>
+-- holding any subset of the policy fields, plus an optional
+-- "version", e.g.
+--   ('sincerity', 'program_12', 'json',
+--    '{"version": "campus-2026", "distraction_penalty": 5}')
+-- ============================================================
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_string, label, description, display_order)
+VALUES ('sincerity', 'policy_version', 'string', 'v1',
+        'Policy Version', 'Label stamped on each scored attempt. Change it whenever the sincerity settings change.', 0)
+ON CONFLICT (category, setting_key) DO NOTHING;
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_number, label, description, display_order)
+VALUES
+    ('sincerity', 'attention_fail_penalty', 'number', 20,
+     'Attention Check Penalty', 'Sincerity points removed per failed attention check.', 7),
+    ('sincerity', 'distraction_penalty', 'number', 10,
+     'Distractor Penalty', 'Sincerity points removed per distractor option chosen.', 8),
+    ('sincerity', 'proctoring_weight', 'number', 0,
+     'Proctoring Weight', 'Share of the proctoring integrity shortfall (100 - integrity score) removed from sincerity.', 9),
+    ('sincerity', 'sincere_threshold', 'number', 80,
+     'Sincere Threshold', 'Minimum sincerity index classed SINCERE.', 10),
+    ('sincerity', 'borderline_threshold', 'number', 50,
+     'Borderline Threshold', 'Minimum sincerity index classed BORDERLINE; anything lower is NOT_SINCERE.', 11)
+ON CONFLICT (category, setting_key) DO NOTHING;
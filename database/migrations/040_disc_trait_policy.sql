@@ -0,0 +1,41 @@
+-- ============================================================
+-- Migration 040: Configurable DISC pure-trait policy
+--
+-- The rules that make a Level 1 result a "pure" trait (e.g. "D")
+-- rather than a blend ("DI") move into settings category 'disc'.
+-- The defaults are the rules the engine has always applied:
+--   top >= 50% of D+I+S+C, or top > 2x every other factor,
+--   ties broken C > D > I > S.
+-- A ratio of 0 disables that rule; pure_require_all makes a trait
+-- pure only when every enabled rule holds.
+--
+-- Per-program overrides are json rows keyed program_<program_id>
+-- holding any subset of the fields plus an optional "version", e.g.
+--   ('disc', 'program_3', 'json',
+--    '{"version": "cxo-strict-1", "pure_share_of_total": 0.6, "pure_require_all": true}')
+--
+-- Bump policy_version on every change: each scored attempt stores
+-- disc_policy_version, the policy itself (disc_policy) and the rule
+-- that decided its code (disc_trait_rule) in its metadata.
+-- ============================================================
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_string, label, description, display_order)
+VALUES
+    ('disc', 'policy_version', 'string', 'v1',
+     'Policy Version', 'Label stamped on each scored Level 1 attempt. Change it whenever the DISC settings change.', 0),
+    ('disc', 'priority', 'string', 'CDIS',
+     'Tie-Break Order', 'Order in which equal factor scores are ranked, highest first. Must name D, I, S and C once each.', 4)
+ON CONFLICT (category, setting_key) DO NOTHING;
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_number, label, description, display_order)
+VALUES
+    ('disc', 'pure_share_of_total', 'number', 0.5,
+     'Pure Trait Share', 'A factor is pure when it is at least this share of the D+I+S+C total (0 disables the rule).', 1),
+    ('disc', 'pure_dominance_ratio', 'number', 2,
+     'Pure Trait Dominance', 'A factor is pure when it is more than this many times every other factor (0 disables the rule).', 2)
+ON CONFLICT (category, setting_key) DO NOTHING;
+
+INSERT INTO originbi_settings (category, setting_key, value_type, value_boolean, label, description, display_order)
+VALUES ('disc', 'pure_require_all', 'boolean', false,
+        'Require All Pure Rules', 'When enabled, a factor is pure only if every enabled rule holds; otherwise one is enough.', 3)
+ON CONFLICT (category, setting_key) DO NOTHING;
@@ -4,6 +4,23 @@ import React, { useState, useEffect, useRef } from "react";
 import { CheckCircleIcon, StepperUpArrowIcon, StepperDownArrowIcon } from '../icons';
 import { useLanguage } from '../../contexts/LanguageContext';
 import { studentService } from '../../lib/services/student.service';
+import { fetchAuthSession } from 'aws-amplify/auth';
+
+// Headers for exam-engine calls. The engine only accepts Cognito access
+// tokens (ID tokens are rejected), so the access token is sent here.
+async function examEngineHeaders(): Promise<Record<string, string>> {
+  const headers: Record<string, string> = { "Content-Type": "application/json" };
+  try {
+    const session = await fetchAuthSession();
+    const token = session.tokens?.accessToken?.toString();
+    if (token) {
+      headers.Authorization = `Bearer ${token}`;
+    }
+  } catch (err) {
+    console.error("[Assessment] Could not load auth session:", err);
+  }
+  return headers;
+}
 
 // --- Interfaces ---
 
@@ -610,7 +627,7 @@ const AssessmentRunner: React.FC<AssessmentRunnerProps> = ({
       const examApiUrl = process.env.NEXT_PUBLIC_EXAM_ENGINE_API_URL;
       const response = await fetch(`${examApiUrl}/api/v1/exam/start`, {
         method: "POST",
-        headers: { "Content-Type": "application/json" },
+        headers: await examEngineHeaders(),
         body: JSON.stringify(payload),
       });
 
@@ -776,7 +793,7 @@ const AssessmentRunner: React.FC<AssessmentRunnerProps> = ({
       const examApiUrl = process.env.NEXT_PUBLIC_EXAM_ENGINE_API_URL;
       await fetch(`${examApiUrl}/api/v1/exam/answer`, {
         method: "POST",
-        headers: { "Content-Type": "application/json" },
+        headers: await examEngineHeaders(),
         body: JSON.stringify(payload),
       });
     } catch (err) {